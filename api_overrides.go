@@ -90,3 +90,175 @@ func overridenRuntimeEvaluate(target *gcd.ChromeTarget, expression string, objec
 
 	return chromeData.Result.Result, chromeData.Result.ExceptionDetails, nil
 }
+
+// SetDefaultBackgroundColorOverride - Sets an override of the default background color of the frame.
+// gcdapi's DOMRGBA omits an alpha of 0, which chrome then treats as opaque, so we always send it.
+// r, g, b - The red, green, blue components in the [0-255] range.
+// a - The alpha component in the [0-1] range.
+func overridenSetDefaultBackgroundColorOverride(target *gcd.ChromeTarget, r, g, b int, a float64) error {
+	color := make(map[string]interface{}, 4)
+	color["r"] = r
+	color["g"] = g
+	color["b"] = b
+	color["a"] = a
+	paramRequest := make(map[string]interface{}, 1)
+	paramRequest["color"] = color
+	_, err := gcdmessage.SendDefaultRequest(target, target.GetSendCh(), &gcdmessage.ParamRequest{Id: target.GetId(), Method: "Emulation.setDefaultBackgroundColorOverride", Params: paramRequest})
+	return err
+}
+
+// SetRequestInterception - Sets the requests to intercept that match the provided patterns.
+// patterns - Requests matching any of these patterns will be forwarded and wait for the corresponding
+// continueInterceptedRequest call. An empty list disables interception.
+func overridenSetRequestInterception(target *gcd.ChromeTarget, patterns []map[string]interface{}) error {
+	paramRequest := make(map[string]interface{}, 1)
+	paramRequest["patterns"] = patterns
+	_, err := gcdmessage.SendDefaultRequest(target, target.GetSendCh(), &gcdmessage.ParamRequest{Id: target.GetId(), Method: "Network.setRequestInterception", Params: paramRequest})
+	return err
+}
+
+// SetDiscoverTargets - Controls whether to discover available targets and notify via targetCreated/targetInfoChanged/targetDestroyed events.
+// discover - Whether to discover available targets.
+func overridenSetDiscoverTargets(target *gcd.ChromeTarget, discover bool) error {
+	paramRequest := make(map[string]interface{}, 1)
+	paramRequest["discover"] = discover
+	_, err := gcdmessage.SendDefaultRequest(target, target.GetSendCh(), &gcdmessage.ParamRequest{Id: target.GetId(), Method: "Target.setDiscoverTargets", Params: paramRequest})
+	return err
+}
+
+// GetTargets - Retrieves a list of available targets.
+// Returns - The list of targets.
+func overridenGetTargets(target *gcd.ChromeTarget) ([]*gcdapi.TargetTargetInfo, error) {
+	resp, err := gcdmessage.SendCustomReturn(target, target.GetSendCh(), &gcdmessage.ParamRequest{Id: target.GetId(), Method: "Target.getTargets", Params: make(map[string]interface{})})
+	if err != nil {
+		return nil, err
+	}
+
+	var chromeData struct {
+		Result struct {
+			TargetInfos []*gcdapi.TargetTargetInfo
+		}
+	}
+
+	if resp == nil {
+		return nil, &gcdmessage.ChromeEmptyResponseErr{}
+	}
+
+	// test if error first
+	cerr := &gcdmessage.ChromeErrorResponse{}
+	json.Unmarshal(resp.Data, cerr)
+	if cerr != nil && cerr.Error != nil {
+		return nil, &gcdmessage.ChromeRequestErr{Resp: cerr}
+	}
+
+	if err := json.Unmarshal(resp.Data, &chromeData); err != nil {
+		return nil, err
+	}
+
+	return chromeData.Result.TargetInfos, nil
+}
+
+// GrantPermissions - Grants specific permissions to the given origin and rejects all others.
+// origin - The origin to grant the permissions to.
+// permissions - The permissions to grant, e.g. clipboardRead.
+func overridenGrantPermissions(target *gcd.ChromeTarget, origin string, permissions []string) error {
+	paramRequest := make(map[string]interface{}, 2)
+	paramRequest["origin"] = origin
+	paramRequest["permissions"] = permissions
+	_, err := gcdmessage.SendDefaultRequest(target, target.GetSendCh(), &gcdmessage.ParamRequest{Id: target.GetId(), Method: "Browser.grantPermissions", Params: paramRequest})
+	return err
+}
+
+// SetEmulatedMedia - Emulates the given media type or media features for CSS media queries.
+// media - Media type to emulate. Empty string disables the override.
+// features - Media features (name to value, e.g. prefers-reduced-motion to reduce) to emulate.
+func overridenSetEmulatedMedia(target *gcd.ChromeTarget, media string, features map[string]string) error {
+	mediaFeatures := make([]map[string]string, 0, len(features))
+	for name, value := range features {
+		mediaFeatures = append(mediaFeatures, map[string]string{"name": name, "value": value})
+	}
+	paramRequest := make(map[string]interface{}, 2)
+	paramRequest["media"] = media
+	paramRequest["features"] = mediaFeatures
+	_, err := gcdmessage.SendDefaultRequest(target, target.GetSendCh(), &gcdmessage.ParamRequest{Id: target.GetId(), Method: "Emulation.setEmulatedMedia", Params: paramRequest})
+	return err
+}
+
+// SetGeolocationOverride - Overrides the Geolocation Position.
+// gcdapi omits zero values, chrome treats any missing value as position unavailable so all are always sent.
+// latitude, longitude - The position in degrees.
+// accuracy - The accuracy of the position in meters.
+func overridenSetGeolocationOverride(target *gcd.ChromeTarget, latitude, longitude, accuracy float64) error {
+	paramRequest := make(map[string]interface{}, 3)
+	paramRequest["latitude"] = latitude
+	paramRequest["longitude"] = longitude
+	paramRequest["accuracy"] = accuracy
+	_, err := gcdmessage.SendDefaultRequest(target, target.GetSendCh(), &gcdmessage.ParamRequest{Id: target.GetId(), Method: "Emulation.setGeolocationOverride", Params: paramRequest})
+	return err
+}
+
+// TerminateExecution - Terminates the javascript currently executing in the target, the interrupted
+// evaluation returns an exception.
+func overridenTerminateExecution(target *gcd.ChromeTarget) error {
+	_, err := gcdmessage.SendDefaultRequest(target, target.GetSendCh(), &gcdmessage.ParamRequest{Id: target.GetId(), Method: "Runtime.terminateExecution"})
+	return err
+}
+
+// DispatchMouseEvent - Dispatches a mouseWheel event.
+// gcdapi omits zero deltas, chrome requires both deltaX and deltaY for mouseWheel events so they are always sent.
+// x, y - Coordinates of the event relative to the main frame's viewport in CSS pixels.
+// deltaX, deltaY - Scroll deltas in CSS pixels.
+func overridenDispatchMouseWheelEvent(target *gcd.ChromeTarget, x, y, deltaX, deltaY float64) error {
+	paramRequest := make(map[string]interface{}, 5)
+	paramRequest["type"] = "mouseWheel"
+	paramRequest["x"] = x
+	paramRequest["y"] = y
+	paramRequest["deltaX"] = deltaX
+	paramRequest["deltaY"] = deltaY
+	_, err := gcdmessage.SendDefaultRequest(target, target.GetSendCh(), &gcdmessage.ParamRequest{Id: target.GetId(), Method: "Input.dispatchMouseEvent", Params: paramRequest})
+	return err
+}
+
+// PrintToPDF - Print page as PDF.
+// gcdapi omits zero margins, which chrome then replaces with its default margins, so params are sent as is.
+// params - The printToPDF parameters such as landscape, paperWidth or marginTop.
+// Returns - Base64-encoded pdf data.
+func overridenPrintToPDF(target *gcd.ChromeTarget, params map[string]interface{}) (string, error) {
+	resp, err := gcdmessage.SendCustomReturn(target, target.GetSendCh(), &gcdmessage.ParamRequest{Id: target.GetId(), Method: "Page.printToPDF", Params: params})
+	if err != nil {
+		return "", err
+	}
+
+	var chromeData struct {
+		Result struct {
+			Data string
+		}
+	}
+
+	if resp == nil {
+		return "", &gcdmessage.ChromeEmptyResponseErr{}
+	}
+
+	// test if error first
+	cerr := &gcdmessage.ChromeErrorResponse{}
+	json.Unmarshal(resp.Data, cerr)
+	if cerr != nil && cerr.Error != nil {
+		return "", &gcdmessage.ChromeRequestErr{Resp: cerr}
+	}
+
+	if err := json.Unmarshal(resp.Data, &chromeData); err != nil {
+		return "", err
+	}
+
+	return chromeData.Result.Data, nil
+}
+
+// PerformanceTimeline.enable - Reports timeline events of the given types, previously buffered events
+// are reported before the method returns. Not available in gcdapi.
+// eventTypes - The types of events to report, an empty list stops reporting events.
+func overridenPerformanceTimelineEnable(target *gcd.ChromeTarget, eventTypes []string) error {
+	paramRequest := make(map[string]interface{}, 1)
+	paramRequest["eventTypes"] = eventTypes
+	_, err := gcdmessage.SendDefaultRequest(target, target.GetSendCh(), &gcdmessage.ParamRequest{Id: target.GetId(), Method: "PerformanceTimeline.enable", Params: paramRequest})
+	return err
+}
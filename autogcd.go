@@ -38,36 +38,52 @@ Dealing with frames is also different than WebDriver. There is no SwitchToFrame,
 to certain methods that require it. You can lookup the these frame documents by finding frame/iframe Elements and
 requesting the document NodeId reference via the GetFrameDocumentNodeId method.
 
-Lastly, dealing with windows... doesn't really work since they open a new tab. A possible solution would be to monitor
-the list of tabs by calling AutoGcd.RefreshTabs() and doing a diff of known versus new. You could then do a Tab.Reload()
-to refresh the page. It is recommended that you clear cache on the tab first so it is possible to trap the various
+Lastly, windows open as new tabs. Register a handler with AutoGcd.OnNewTab to be given a Tab for each one as it is
+created, use AutoGcd.ExpectPopup to get the Tab opened by a click or script, or monitor the list of tabs by calling AutoGcd.RefreshTabList() and doing a diff of known versus new. You
+could then do a Tab.Reload() to refresh the page. It is recommended that you clear cache on the tab first so it is possible to trap the various
 network events. There are other dirty hacks you could do as well, such as injecting script to override window.open,
 or rewriting links etc.
 */
 package autogcd
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/wirepair/gcd"
+	"github.com/wirepair/gcd/gcdapi"
 )
 
+// For handling tabs created by the browser, such as popups
+type NewTabHandlerFunc func(tab *Tab)
+
 type AutoGcd struct {
-	debugger *gcd.Gcd
-	settings *Settings
-	tabLock  *sync.RWMutex
-	tabs     map[string]*Tab
-	shutdown bool
+	debugger       *gcd.Gcd
+	settings       *Settings
+	tabLock        *sync.RWMutex
+	listenerLock   *sync.Mutex // serializes starting and stopping the new tab listener
+	tabs           map[string]*Tab
+	shutdown       bool
+	newTabHandler  NewTabHandlerFunc // called with tabs created by the browser
+	newTabListener *Tab              // the tab we receive target created events on
+	popupWaiters   []chan *Tab       // ExpectPopup calls waiting for the next tab created by the browser
+	pendingNewTabs int               // NewTab calls which have not registered their target yet
+	newTabCond     *sync.Cond        // signaled on tabLock when a NewTab call registers its target
+	handledTargets map[string]bool   // targets not to pass to OnNewTab/ExpectPopup, already open or delivered
 }
 
 // Creates a new AutoGcd based off the provided settings.
 func NewAutoGcd(settings *Settings) *AutoGcd {
 	auto := &AutoGcd{settings: settings}
 	auto.tabLock = &sync.RWMutex{}
+	auto.listenerLock = &sync.Mutex{}
+	auto.newTabCond = sync.NewCond(auto.tabLock)
 	auto.tabs = make(map[string]*Tab)
+	auto.handledTargets = make(map[string]bool)
 	auto.debugger = gcd.NewChromeDebugger()
 	auto.debugger.SetTerminationHandler(auto.defaultTerminationHandler)
 	if len(settings.extensions) > 0 {
@@ -114,6 +130,7 @@ func (auto *AutoGcd) Start() error {
 	for _, tab := range tabs {
 		t, err := open(tab)
 		if err != nil {
+			auto.tabLock.Unlock()
 			return err
 		}
 		auto.tabs[tab.Target.Id] = t
@@ -165,6 +182,7 @@ func (auto *AutoGcd) RefreshTabList() (map[string]*Tab, error) {
 	for _, newTab := range newTabs {
 		t, err := open(newTab)
 		if err != nil {
+			auto.tabLock.Unlock()
 			return nil, err
 		}
 		auto.tabs[newTab.Target.Id] = t
@@ -173,6 +191,267 @@ func (auto *AutoGcd) RefreshTabList() (map[string]*Tab, error) {
 	return auto.GetAllTabs(), nil
 }
 
+// Calls newTabFn with a Tab for each page target the browser creates, such as popups from window.open
+// or target=_blank links. Target events are received on the first "visual" tab, if it is closed another
+// page tab takes over. Pass nil to stop listening for new tabs.
+func (auto *AutoGcd) OnNewTab(newTabFn NewTabHandlerFunc) error {
+	auto.listenerLock.Lock()
+	defer auto.listenerLock.Unlock()
+
+	auto.tabLock.Lock()
+	auto.newTabHandler = newTabFn
+	auto.tabLock.Unlock()
+	return auto.updateNewTabListener()
+}
+
+// Runs trigger, such as clicking a link which calls window.open, and returns the Tab of the first page
+// target the browser creates after it. Listening starts before trigger is called so the popup can not be
+// missed. Concurrent calls are given popups in the order they started waiting. Returns a TimeoutErr if no
+// tab is created within timeout.
+func (auto *AutoGcd) ExpectPopup(trigger func() error, timeout time.Duration) (*Tab, error) {
+	popupCh := make(chan *Tab, 1)
+
+	auto.listenerLock.Lock()
+	auto.tabLock.Lock()
+	auto.popupWaiters = append(auto.popupWaiters, popupCh)
+	auto.tabLock.Unlock()
+	err := auto.updateNewTabListener()
+	auto.listenerLock.Unlock()
+
+	defer func() {
+		auto.listenerLock.Lock()
+		defer auto.listenerLock.Unlock()
+
+		auto.tabLock.Lock()
+		for i, waiter := range auto.popupWaiters {
+			if waiter == popupCh {
+				auto.popupWaiters = append(auto.popupWaiters[:i], auto.popupWaiters[i+1:]...)
+				break
+			}
+		}
+		// a popup given to us after we stopped waiting goes to the next waiter.
+		select {
+		case popup := <-popupCh:
+			auto.notifyPopupWaiters(popup)
+		default:
+		}
+		auto.tabLock.Unlock()
+		auto.updateNewTabListener()
+	}()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := trigger(); err != nil {
+		return nil, err
+	}
+
+	timeoutTimer := time.NewTimer(timeout)
+	defer timeoutTimer.Stop()
+
+	select {
+	case popup := <-popupCh:
+		return popup, nil
+	case <-timeoutTimer.C:
+		return nil, &TimeoutErr{Message: "waiting for popup"}
+	}
+}
+
+// Starts listening for new tabs if there is a new tab handler or a popup waiter, and stops if there
+// are neither. listenerLock must be held so enabling and disabling discovery can not interleave, tabLock
+// must not be held as this waits on the browser.
+func (auto *AutoGcd) updateNewTabListener() error {
+	auto.tabLock.RLock()
+	wanted := auto.newTabHandler != nil || len(auto.popupWaiters) > 0
+	listening := auto.newTabListener != nil
+	auto.tabLock.RUnlock()
+
+	if wanted && !listening {
+		return auto.startNewTabListener()
+	}
+
+	if !wanted && listening {
+		return auto.stopNewTabListener()
+	}
+	return nil
+}
+
+// Subscribes to target created events on the first page tab. listenerLock must be held and tabLock must not be.
+func (auto *AutoGcd) startNewTabListener() error {
+	var listener *Tab
+	auto.tabLock.RLock()
+	for _, tab := range auto.tabs {
+		if tab.Target.Type == "page" {
+			listener = tab
+			break
+		}
+	}
+	auto.tabLock.RUnlock()
+
+	if listener == nil {
+		return &InvalidTabErr{Message: "no Page tab types found to listen for new tabs"}
+	}
+
+	listener.Subscribe("Target.targetCreated", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.TargetTargetCreatedEvent{}
+		if err := json.Unmarshal(payload, message); err != nil || message.Params.TargetInfo == nil {
+			return
+		}
+		if message.Params.TargetInfo.Type == "page" {
+			go auto.openCreatedTarget(message.Params.TargetInfo.TargetId)
+		}
+	})
+
+	// discovery reports existing targets as created too, they are not new tabs.
+	targetInfos, err := overridenGetTargets(listener.ChromeTarget)
+	if err != nil {
+		listener.Unsubscribe("Target.targetCreated")
+		return err
+	}
+
+	auto.tabLock.Lock()
+	for _, targetInfo := range targetInfos {
+		auto.handledTargets[targetInfo.TargetId] = true
+	}
+	auto.newTabListener = listener
+	auto.tabLock.Unlock()
+
+	if err := overridenSetDiscoverTargets(listener.ChromeTarget, true); err != nil {
+		auto.stopNewTabListener()
+		return err
+	}
+	return nil
+}
+
+// Stops listening for target created events. listenerLock must be held and tabLock must not be.
+func (auto *AutoGcd) stopNewTabListener() error {
+	auto.tabLock.Lock()
+	listener := auto.newTabListener
+	auto.newTabListener = nil
+	auto.tabLock.Unlock()
+
+	if listener == nil {
+		return nil
+	}
+	listener.Unsubscribe("Target.targetCreated")
+	return overridenSetDiscoverTargets(listener.ChromeTarget, false)
+}
+
+// Opens the target created by the browser and passes it to the new tab handler and ExpectPopup calls.
+// Targets we created via NewTab also raise target created events, so we wait for any NewTab calls in
+// progress to record their target and skip handled targets. Only targetId is opened, if it was already
+// opened, such as by RefreshTabList, the known tab is delivered. The browser may not list the target
+// immediately, so we retry for a time. Targets are opened without holding tabLock.
+func (auto *AutoGcd) openCreatedTarget(targetId string) {
+	for i := 0; i < 20; i++ {
+		auto.tabLock.Lock()
+		for auto.pendingNewTabs > 0 {
+			auto.newTabCond.Wait()
+		}
+
+		if auto.handledTargets[targetId] {
+			auto.tabLock.Unlock()
+			return
+		}
+
+		if existing, ok := auto.tabs[targetId]; ok {
+			auto.deliverCreatedTab(existing)
+			return
+		}
+
+		listener := auto.newTabListener
+		auto.tabLock.Unlock()
+
+		if listener == nil {
+			return
+		}
+
+		createdTarget, err := auto.getNewTarget(listener, targetId)
+		if err != nil {
+			return
+		}
+
+		if createdTarget == nil {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		createdTab, err := open(createdTarget)
+		if err != nil {
+			return
+		}
+
+		auto.tabLock.Lock()
+		if existing, ok := auto.tabs[targetId]; ok {
+			// registered while we were opening it, keep the known tab.
+			createdTab.Close()
+			createdTab = existing
+		} else {
+			auto.tabs[targetId] = createdTab
+		}
+
+		if auto.handledTargets[targetId] {
+			auto.tabLock.Unlock()
+			return
+		}
+		auto.deliverCreatedTab(createdTab)
+		return
+	}
+}
+
+// Connects to targetId only, by treating every other target the browser lists as known. Returns nil
+// if the browser does not list targetId yet.
+func (auto *AutoGcd) getNewTarget(listener *Tab, targetId string) (*gcd.ChromeTarget, error) {
+	targetInfos, err := overridenGetTargets(listener.ChromeTarget)
+	if err != nil {
+		return nil, err
+	}
+
+	knownIds := make(map[string]struct{}, len(targetInfos))
+	for _, targetInfo := range targetInfos {
+		if targetInfo.TargetId != targetId {
+			knownIds[targetInfo.TargetId] = struct{}{}
+		}
+	}
+
+	newTargets, err := auto.debugger.GetNewTargets(knownIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, newTarget := range newTargets {
+		if newTarget.Target.Id == targetId {
+			return newTarget, nil
+		}
+	}
+	return nil, nil
+}
+
+// Marks the tab as handled and passes it to ExpectPopup calls and the new tab handler. tabLock must
+// be held and is released before calling the handler.
+func (auto *AutoGcd) deliverCreatedTab(tab *Tab) {
+	auto.handledTargets[tab.Target.Id] = true
+	auto.notifyPopupWaiters(tab)
+	newTabFn := auto.newTabHandler
+	auto.tabLock.Unlock()
+
+	if newTabFn != nil {
+		newTabFn(tab)
+	}
+}
+
+// Gives the tab to the longest waiting ExpectPopup call which has not been given one yet, tabLock must be held.
+func (auto *AutoGcd) notifyPopupWaiters(tab *Tab) {
+	for _, waiter := range auto.popupWaiters {
+		select {
+		case waiter <- tab:
+			return
+		default: // already given a popup
+		}
+	}
+}
+
 // Returns the first "visual" tab.
 func (auto *AutoGcd) GetTab() (*Tab, error) {
 	auto.tabLock.RLock()
@@ -212,12 +491,27 @@ func (auto *AutoGcd) ActivateTabById(id string) error {
 
 // Creates a new tab
 func (auto *AutoGcd) NewTab() (*Tab, error) {
+	// mark the tab as pending so target created events wait for us to register it.
+	auto.tabLock.Lock()
+	auto.pendingNewTabs++
+	auto.tabLock.Unlock()
+
 	target, err := auto.debugger.NewTab()
+
+	auto.tabLock.Lock()
+	defer auto.tabLock.Unlock()
+	defer auto.newTabCond.Broadcast()
+	auto.pendingNewTabs--
+
 	if err != nil {
 		return nil, &InvalidTabErr{Message: "unable to create tab: " + err.Error()}
 	}
-	auto.tabLock.Lock()
-	defer auto.tabLock.Unlock()
+	auto.handledTargets[target.Target.Id] = true
+
+	// we may have already opened it, such as by RefreshTabList
+	if tab, ok := auto.tabs[target.Target.Id]; ok {
+		return tab, nil
+	}
 
 	tab, err := open(target)
 	if err != nil {
@@ -227,19 +521,30 @@ func (auto *AutoGcd) NewTab() (*Tab, error) {
 	return tab, nil
 }
 
-// Closes the provided tab.
+// Closes the provided tab. If the tab was receiving target created events for OnNewTab or ExpectPopup,
+// another page tab takes over, returning an error if there is none.
 func (auto *AutoGcd) CloseTab(tab *Tab) error {
-	tab.close() // kill listening go routines
+	var listenErr error
 
-	if err := auto.debugger.CloseTab(tab.ChromeTarget); err != nil {
-		return err
+	auto.listenerLock.Lock()
+	auto.tabLock.Lock()
+	delete(auto.tabs, tab.Target.Id)
+	delete(auto.handledTargets, tab.Target.Id)
+	isListener := auto.newTabListener == tab
+	auto.tabLock.Unlock()
+
+	if isListener {
+		auto.stopNewTabListener()
+		listenErr = auto.updateNewTabListener()
 	}
+	auto.listenerLock.Unlock()
 
-	auto.tabLock.Lock()
-	defer auto.tabLock.Unlock()
+	tab.Close() // unsubscribe and kill listening go routines
 
-	delete(auto.tabs, tab.Target.Id)
-	return nil
+	if err := auto.debugger.CloseTab(tab.ChromeTarget); err != nil {
+		return err
+	}
+	return listenErr
 }
 
 // Closes a tab based off the tab id.
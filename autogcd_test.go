@@ -135,6 +135,260 @@ func TestCloseTab(t *testing.T) {
 	}
 }
 
+func TestOnNewTab(t *testing.T) {
+	auto := testDefaultStartup(t)
+	defer auto.Shutdown()
+
+	newTabs := make(chan *Tab, 1)
+	if err := auto.OnNewTab(func(tab *Tab) { newTabs <- tab }); err != nil {
+		t.Fatalf("error listening for new tabs: %s\n", err)
+	}
+
+	tab, err := auto.GetTab()
+	if err != nil {
+		t.Fatalf("error getting tab: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if _, err := tab.EvaluateScript("window.open('" + testServerAddr + "button.html')"); err != nil {
+		t.Fatalf("error opening popup: %s\n", err)
+	}
+
+	select {
+	case popup := <-newTabs:
+		if popup.Target.Id == tab.Target.Id {
+			t.Fatalf("expected a new tab for the popup")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for popup tab")
+	}
+
+	if err := auto.OnNewTab(nil); err != nil {
+		t.Fatalf("error stopping new tab listener: %s\n", err)
+	}
+}
+
+func TestOnNewTabMultiplePopups(t *testing.T) {
+	auto := testDefaultStartup(t)
+	defer auto.Shutdown()
+
+	newTabs := make(chan *Tab, 2)
+	if err := auto.OnNewTab(func(tab *Tab) { newTabs <- tab }); err != nil {
+		t.Fatalf("error listening for new tabs: %s\n", err)
+	}
+	defer auto.OnNewTab(nil)
+
+	tab, err := auto.GetTab()
+	if err != nil {
+		t.Fatalf("error getting tab: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	// each evaluation is its own user gesture, so the popup blocker allows both.
+	for _, page := range []string{"button.html", "links.html"} {
+		if _, err := tab.EvaluateScript("window.open('" + testServerAddr + page + "')"); err != nil {
+			t.Fatalf("error opening popup: %s\n", err)
+		}
+	}
+
+	popups := make(map[string]bool)
+	for len(popups) < 2 {
+		select {
+		case popup := <-newTabs:
+			popups[popup.Target.Id] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected both popups to be passed to the handler got: %d\n", len(popups))
+		}
+	}
+}
+
+func TestOnNewTabIgnoresNewTab(t *testing.T) {
+	auto := testDefaultStartup(t)
+	defer auto.Shutdown()
+
+	newTabs := make(chan *Tab, 5)
+	if err := auto.OnNewTab(func(tab *Tab) { newTabs <- tab }); err != nil {
+		t.Fatalf("error listening for new tabs: %s\n", err)
+	}
+	defer auto.OnNewTab(nil)
+
+	tabLen := len(auto.GetAllTabs())
+	for i := 0; i < 3; i++ {
+		if _, err := auto.NewTab(); err != nil {
+			t.Fatalf("error creating new tab: %s\n", err)
+		}
+	}
+
+	select {
+	case tab := <-newTabs:
+		t.Fatalf("expected tabs created by NewTab to not be passed to the handler got: %s\n", tab.Target.Id)
+	case <-time.After(2 * time.Second):
+	}
+
+	if tabLen+3 != len(auto.GetAllTabs()) {
+		t.Fatalf("expected exactly one tab per NewTab call got: %d\n", len(auto.GetAllTabs())-tabLen)
+	}
+}
+
+func TestOnNewTabListenerClosed(t *testing.T) {
+	auto := testDefaultStartup(t)
+	defer auto.Shutdown()
+
+	if _, err := auto.NewTab(); err != nil {
+		t.Fatalf("error creating new tab: %s\n", err)
+	}
+
+	newTabs := make(chan *Tab, 1)
+	if err := auto.OnNewTab(func(tab *Tab) { newTabs <- tab }); err != nil {
+		t.Fatalf("error listening for new tabs: %s\n", err)
+	}
+	defer auto.OnNewTab(nil)
+
+	if err := auto.CloseTab(auto.newTabListener); err != nil {
+		t.Fatalf("error closing listening tab: %s\n", err)
+	}
+
+	tab, err := auto.GetTab()
+	if err != nil {
+		t.Fatalf("error getting tab: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if _, err := tab.EvaluateScript("window.open('" + testServerAddr + "button.html')"); err != nil {
+		t.Fatalf("error opening popup: %s\n", err)
+	}
+
+	select {
+	case <-newTabs:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for popup tab after the listening tab was closed")
+	}
+}
+
+func TestExpectPopup(t *testing.T) {
+	auto := testDefaultStartup(t)
+	defer auto.Shutdown()
+
+	tab, err := auto.GetTab()
+	if err != nil {
+		t.Fatalf("error getting tab: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	popup, err := auto.ExpectPopup(func() error {
+		_, err := tab.EvaluateScript("window.open('" + testServerAddr + "button.html')")
+		return err
+	}, 5*time.Second)
+	if err != nil {
+		t.Fatalf("error waiting for popup: %s\n", err)
+	}
+
+	if popup.Target.Id == tab.Target.Id {
+		t.Fatalf("expected a new tab for the popup")
+	}
+
+	err = popup.WaitFor(testWaitRate, testWaitTimeout, UrlEquals(popup, testServerAddr+"button.html"))
+	if err != nil {
+		t.Fatalf("expected popup to load button.html: %s\n", err)
+	}
+
+	_, err = auto.ExpectPopup(func() error { return nil }, 500*time.Millisecond)
+	if _, ok := err.(*TimeoutErr); !ok {
+		t.Fatalf("expected TimeoutErr when no popup opens got: %v\n", err)
+	}
+}
+
+func TestExpectPopupConcurrent(t *testing.T) {
+	auto := testDefaultStartup(t)
+	defer auto.Shutdown()
+
+	tab, err := auto.GetTab()
+	if err != nil {
+		t.Fatalf("error getting tab: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	type result struct {
+		popup *Tab
+		err   error
+	}
+	results := make(chan result, 2)
+	for _, page := range []string{"button.html", "links.html"} {
+		go func(page string) {
+			popup, err := auto.ExpectPopup(func() error {
+				_, err := tab.EvaluateScript("window.open('" + testServerAddr + page + "')")
+				return err
+			}, 5*time.Second)
+			results <- result{popup, err}
+		}(page)
+	}
+
+	popups := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		r := <-results
+		if r.err != nil {
+			t.Fatalf("error waiting for popup: %s\n", r.err)
+		}
+		popups[r.popup.Target.Id] = true
+	}
+
+	if len(popups) != 2 {
+		t.Fatalf("expected each ExpectPopup call to be given a different popup")
+	}
+}
+
+func TestExpectPopupIgnoresNewTab(t *testing.T) {
+	auto := testDefaultStartup(t)
+	defer auto.Shutdown()
+
+	tab, err := auto.GetTab()
+	if err != nil {
+		t.Fatalf("error getting tab: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	var ownTab *Tab
+	popup, err := auto.ExpectPopup(func() error {
+		if ownTab, err = auto.NewTab(); err != nil {
+			return err
+		}
+		// give the target created event for our own tab time to arrive first
+		time.Sleep(500 * time.Millisecond)
+		_, err := tab.EvaluateScript("window.open('" + testServerAddr + "button.html')")
+		return err
+	}, 5*time.Second)
+	if err != nil {
+		t.Fatalf("error waiting for popup: %s\n", err)
+	}
+
+	if popup.Target.Id == ownTab.Target.Id {
+		t.Fatalf("expected the popup and not the tab created by NewTab")
+	}
+
+	err = popup.WaitFor(testWaitRate, testWaitTimeout, UrlEquals(popup, testServerAddr+"button.html"))
+	if err != nil {
+		t.Fatalf("expected popup to load button.html: %s\n", err)
+	}
+}
+
 func TestChromeTermination(t *testing.T) {
 	auto := testDefaultStartup(t)
 	doneCh := make(chan struct{})
@@ -159,7 +413,7 @@ func TestChromeTermination(t *testing.T) {
 DONE:
 }
 
-func testDefaultStartup(t *testing.T) *AutoGcd {
+func testDefaultStartup(t testing.TB) *AutoGcd {
 	s := NewSettings(testPath, testRandomDir(t))
 	s.RemoveUserDir(true)
 	s.AddStartupFlags(testStartupFlags)
@@ -179,7 +433,7 @@ func testServer() {
 	go http.Serve(testListener, http.FileServer(http.Dir("testdata")))
 }
 
-func testRandomPort(t *testing.T) string {
+func testRandomPort(t testing.TB) string {
 	l, err := net.Listen("tcp", ":0")
 	if err != nil {
 		t.Fatal(err)
@@ -189,7 +443,7 @@ func testRandomPort(t *testing.T) string {
 	return randPort
 }
 
-func testRandomDir(t *testing.T) string {
+func testRandomDir(t testing.TB) string {
 	dir, err := ioutil.TempDir(testDir, "autogcd")
 	if err != nil {
 		t.Fatalf("error getting temp dir: %s\n", err)
@@ -25,7 +25,9 @@ THE SOFTWARE.
 package autogcd
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"strings"
 	"sync"
 	"time"
@@ -68,6 +70,14 @@ func (e *ElementNotReadyErr) Error() string {
 	return "this element is not ready"
 }
 
+// The element does not contain any text
+type ElementHasNoTextErr struct {
+}
+
+func (e *ElementHasNoTextErr) Error() string {
+	return "this element has no text"
+}
+
 // When the dimensions of an element are incorrect to calculate the centroid
 type InvalidDimensionsErr struct {
 	Message string
@@ -137,12 +147,12 @@ func (e *Element) populateElement(node *gcdapi.DOMNode) {
 		e.updateAttribute(node.Attributes[i], node.Attributes[i+1])
 	}
 
-	// close it
+	// close it, checking ready under the lock as the same node may be populated concurrently.
+	e.lock.Lock()
+	defer e.lock.Unlock()
 	if !e.ready {
 		close(e.readyGate)
 	}
-	e.lock.Lock()
-	defer e.lock.Unlock()
 	e.ready = true
 }
 
@@ -376,6 +386,28 @@ func (e *Element) removeChild(removedNodeId int) {
 	}
 }
 
+// Returns the node ids of our children and ContentDocument, if any, for invalidating them.
+func (e *Element) ownedNodeIds() []int {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	ids := make([]int, 0)
+	if e.node == nil {
+		return ids
+	}
+
+	if e.node.ContentDocument != nil {
+		ids = append(ids, e.node.ContentDocument.NodeId)
+	}
+
+	for _, child := range e.node.Children {
+		if child != nil {
+			ids = append(ids, child.NodeId)
+		}
+	}
+	return ids
+}
+
 // Get child node ids, returns nil if node is not ready
 func (e *Element) GetChildNodeIds() ([]int, error) {
 	e.lock.RLock()
@@ -396,6 +428,118 @@ func (e *Element) GetChildNodeIds() ([]int, error) {
 	return ids, nil
 }
 
+// Returns the child elements of this element, including text nodes, in document order. If chrome
+// has not sent us the children yet we request them and wait up to the tab's element timeout.
+func (e *Element) GetChildren() ([]*Element, error) {
+	if err := e.WaitForReady(); err != nil {
+		return nil, err
+	}
+
+	ids, err := e.GetChildNodeIds()
+	if _, ok := err.(*ElementHasNoChildrenErr); ok && e.hasUnloadedChildren() {
+		ids, err = e.requestChildren()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	children := make([]*Element, 0, len(ids))
+	for _, id := range ids {
+		child, _ := e.tab.GetElementByNodeId(id)
+		children = append(children, child)
+	}
+	return children, nil
+}
+
+// Returns the parent element of this element, or ElementNotFoundErr for the #document or for
+// elements chrome has not yet told us the parent of.
+func (e *Element) GetParent() (*Element, error) {
+	if err := e.WaitForReady(); err != nil {
+		return nil, err
+	}
+
+	e.lock.RLock()
+	id := e.id
+	invalidated := e.invalidated
+	parentId := 0
+	if e.node != nil {
+		parentId = e.node.ParentId
+	}
+	e.lock.RUnlock()
+
+	if invalidated {
+		return nil, &InvalidElementErr{}
+	}
+
+	if parentId == 0 {
+		return nil, &ElementNotFoundErr{Message: fmt.Sprintf("parent of nodeId %d", id)}
+	}
+
+	parent, _ := e.tab.GetElementByNodeId(parentId)
+	return parent, nil
+}
+
+// true if chrome reported children for this node but has not sent them to us yet.
+func (e *Element) hasUnloadedChildren() bool {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	return e.childNodeCount > 0 && (e.node == nil || len(e.node.Children) == 0)
+}
+
+// Requests the immediate children of this element and waits for the setChildNodes event to populate them.
+func (e *Element) requestChildren() ([]int, error) {
+	e.lock.RLock()
+	id := e.id
+	invalidated := e.invalidated
+	e.lock.RUnlock()
+
+	if invalidated {
+		return nil, &InvalidElementErr{}
+	}
+
+	if _, err := e.tab.DOM.RequestChildNodes(id, 1, false); err != nil {
+		return nil, err
+	}
+
+	timeout := time.NewTimer(e.tab.elementTimeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if ids, err := e.GetChildNodeIds(); err == nil {
+				return ids, nil
+			}
+			if e.IsInvalid() {
+				return nil, &InvalidElementErr{}
+			}
+		case <-timeout.C:
+			return nil, &ElementHasNoChildrenErr{}
+		}
+	}
+}
+
+// Returns true if any descendant of this element matches the selector, false if none do.
+func (e *Element) Contains(selector string) (bool, error) {
+	e.lock.RLock()
+	id := e.id
+	invalidated := e.invalidated
+	e.lock.RUnlock()
+
+	if invalidated {
+		return false, &InvalidElementErr{}
+	}
+
+	nodeId, err := e.tab.DOM.QuerySelector(id, selector)
+	if err != nil {
+		return false, err
+	}
+	return nodeId != 0, nil
+}
+
 // Returns the tag name (input, div etc) if the element is in a ready state.
 func (e *Element) GetTagName() (string, error) {
 	e.lock.RLock()
@@ -430,6 +574,64 @@ func (e *Element) GetCharacterData() (string, error) {
 	return e.characterData, nil
 }
 
+// Returns the text of the element as rendered to the user (innerText), falling back to textContent
+// for elements without innerText such as svg. The text is not trimmed. Returns InvalidElementErr if
+// the element has been removed from the DOM.
+func (e *Element) GetText() (string, error) {
+	if e.IsInvalid() {
+		return "", &InvalidElementErr{}
+	}
+
+	e.lock.RLock()
+	id := e.id
+	e.lock.RUnlock()
+
+	return e.tab.nodeText(id)
+}
+
+// Measures the element's text as laid out from the client rects of a range over its contents, for
+// testing truncation and wrapping which the box model can not show for inline text. Returns an
+// ElementHasNoTextErr if the element contains no text.
+func (e *Element) GetTextMetrics() (*TextMetrics, error) {
+	if e.IsInvalid() {
+		return nil, &InvalidElementErr{}
+	}
+
+	e.lock.RLock()
+	id := e.id
+	e.lock.RUnlock()
+
+	measure := `function() {
+	if (!this.textContent || !this.textContent.trim()) { return null; }
+	var range = document.createRange();
+	range.selectNodeContents(this);
+	var bounds = range.getBoundingClientRect();
+	var rects = range.getClientRects();
+	var tops = {};
+	var lines = 0;
+	for (var i = 0; i < rects.length; i++) {
+		var top = Math.round(rects[i].top);
+		if (rects[i].width > 0 && !tops[top]) { tops[top] = true; lines++; }
+	}
+	return JSON.stringify({width: bounds.width, height: bounds.height, lines: lines});
+}`
+	rro, err := e.tab.callFunctionOnNode(id, measure)
+	if err != nil {
+		return nil, err
+	}
+
+	metricsJSON, ok := rro.Value.(string)
+	if !ok {
+		return nil, &ElementHasNoTextErr{}
+	}
+
+	metrics := &TextMetrics{}
+	if err := json.Unmarshal([]byte(metricsJSON), metrics); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
 // Returns true if the node is enabled, only makes sense for form controls.
 // Element must be in a ready state.
 func (e *Element) IsEnabled() (bool, error) {
@@ -450,6 +652,42 @@ func (e *Element) IsEnabled() (bool, error) {
 	return true, nil
 }
 
+// Returns true if the element is rendered and takes up space on the page. Elements with display none,
+// visibility hidden, zero opacity or no area, or inside such an element, are not visible.
+func (e *Element) IsVisible() (bool, error) {
+	if e.IsInvalid() {
+		return false, &InvalidElementErr{}
+	}
+
+	e.lock.RLock()
+	id := e.id
+	e.lock.RUnlock()
+
+	isVisible := `function() {
+	var el = this.nodeType === Node.ELEMENT_NODE ? this : this.parentElement;
+	if (!el || !el.isConnected) { return false; }
+	var rect = el.getBoundingClientRect();
+	if (rect.width === 0 || rect.height === 0) { return false; }
+	var style = window.getComputedStyle(el);
+	if (style.visibility === 'hidden' || style.visibility === 'collapse') { return false; }
+	for (; el; el = el.parentElement) {
+		style = window.getComputedStyle(el);
+		if (style.display === 'none' || style.opacity === '0') { return false; }
+	}
+	return true;
+}`
+	rro, err := e.tab.callFunctionOnNode(id, isVisible)
+	if err != nil {
+		return false, err
+	}
+
+	visible, ok := rro.Value.(bool)
+	if !ok {
+		return false, &ScriptEvaluationErr{Message: "visibility was not a boolean", ExceptionText: "unable to check element visibility"}
+	}
+	return visible, nil
+}
+
 // Simulate WebDrivers checked propertyname check
 func (e *Element) IsSelected() (bool, error) {
 	e.lock.RLock()
@@ -495,8 +733,14 @@ func (e *Element) GetComputedCssStyle() (map[string]string, error) {
 	return styleMap, nil
 }
 
-// Get attributes of the node returning a map of name,value pairs.
+// Get attributes of the node returning a map of name,value pairs. The map is a copy which is
+// empty if the element has no attributes, it is safe to modify. Returns InvalidElementErr if the
+// element has been removed from the DOM.
 func (e *Element) GetAttributes() (map[string]string, error) {
+	if e.IsInvalid() {
+		return nil, &InvalidElementErr{}
+	}
+
 	e.lock.RLock()
 	attr, err := e.tab.DOM.GetAttributes(e.id)
 	e.lock.RUnlock()
@@ -504,11 +748,21 @@ func (e *Element) GetAttributes() (map[string]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	for i := 0; i < len(attr); i += 2 {
-		e.updateAttribute(attr[i], attr[i+1])
+
+	attributes := make(map[string]string, len(attr)/2)
+	for i := 0; i+1 < len(attr); i += 2 {
+		attributes[attr[i]] = attr[i+1]
 	}
 
-	return e.attributes, nil
+	// replace rather than update our attributes so removed ones are not kept.
+	e.lock.Lock()
+	e.attributes = make(map[string]string, len(attributes))
+	for name, value := range attributes {
+		e.attributes[name] = value
+	}
+	e.lock.Unlock()
+
+	return attributes, nil
 }
 
 // Gets a single attribute by name, returns empty string if it does not exist
@@ -533,8 +787,13 @@ func (e *Element) HasAttribute(name string) bool {
 	return exists
 }
 
-// SetAttributeValue sets an element's attribute with name to value.
+// SetAttributeValue sets an element's attribute with name to value. Returns InvalidElementErr if the
+// element has been removed from the DOM.
 func (e *Element) SetAttributeValue(name, value string) error {
+	if e.IsInvalid() {
+		return &InvalidElementErr{}
+	}
+
 	e.lock.Lock()
 	defer e.lock.Unlock()
 
@@ -548,6 +807,24 @@ func (e *Element) SetAttributeValue(name, value string) error {
 	return nil
 }
 
+// RemoveAttribute removes the attribute with name from the element. Returns InvalidElementErr if the
+// element has been removed from the DOM.
+func (e *Element) RemoveAttribute(name string) error {
+	if e.IsInvalid() {
+		return &InvalidElementErr{}
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if _, err := e.tab.DOM.RemoveAttribute(e.id, name); err != nil {
+		return err
+	}
+
+	delete(e.attributes, name)
+	return nil
+}
+
 // Works like WebDriver's clear(), simply sets the attribute value for input
 // or clears the value for textarea. This element must be ready so we can
 // properly read the nodeName value.
@@ -561,20 +838,51 @@ func (e *Element) Clear() error {
 		return &ElementNotReadyErr{}
 	}
 
-	if e.nodeName != "textarea" || e.nodeName != "input" {
+	if e.nodeName != "textarea" && e.nodeName != "input" {
 		return &IncorrectElementTypeErr{ExpectedName: "textarea or input", NodeName: e.nodeName}
 	}
 
-	if e.nodeName == "textarea" {
-		_, err = e.tab.DOM.SetNodeValue(e.id, "")
+	// the value attribute only holds the default, the current value is a property.
+	_, err = e.tab.callFunctionOnNode(e.id, "function() { this.value = ''; this.dispatchEvent(new Event('input', {bubbles: true})); }")
+	return err
+}
+
+// Replaces the text of a contenteditable element, such as a rich text editor, firing beforeinput and input
+// events so editors which ignore key events register the change. If the beforeinput event is cancelled the
+// text is left to the editor's handler. Returns IncorrectElementTypeErr if the element is not editable.
+func (e *Element) SetContentEditableText(text string) error {
+	if e.IsInvalid() {
+		return &InvalidElementErr{}
+	}
+
+	e.lock.RLock()
+	id := e.id
+	nodeName := e.nodeName
+	e.lock.RUnlock()
+
+	setText := `function(text) {
+	if (!this.isContentEditable) { return false; }
+	this.focus();
+	var before = new InputEvent('beforeinput', {bubbles: true, cancelable: true, inputType: 'insertText', data: text});
+	if (this.dispatchEvent(before)) {
+		this.textContent = text;
 	}
-	if e.nodeName == "input" {
-		_, err = e.tab.DOM.SetAttributeValue(e.id, "value", "")
+	this.dispatchEvent(new InputEvent('input', {bubbles: true, inputType: 'insertText', data: text}));
+	return true;
+}`
+	rro, err := e.tab.callFunctionOnNode(id, setText, text)
+	if err != nil {
+		return err
 	}
-	return err
+
+	if editable, ok := rro.Value.(bool); !ok || !editable {
+		return &IncorrectElementTypeErr{ExpectedName: "contenteditable element", NodeName: nodeName}
+	}
+	return nil
 }
 
-// Clicks the center of the element.
+// Clicks the center of the element's content box, returns InvalidElementErr if the element
+// was removed or InvalidDimensionsErr if it has no area.
 func (e *Element) Click() error {
 	x, y, err := e.getCenter()
 	if err != nil {
@@ -585,6 +893,46 @@ func (e *Element) Click() error {
 	return e.tab.Click(float64(x), float64(y))
 }
 
+// Right clicks the center of the element so sites with custom javascript context menus show them. If
+// the right click does not deliver a contextmenu event to the element (for example it is covered by
+// another element), a synthetic one is dispatched on it. The native browser context menu can not be driven.
+func (e *Element) ContextMenu() error {
+	x, y, err := e.getCenter()
+	if err != nil {
+		return err
+	}
+
+	e.lock.RLock()
+	id := e.id
+	e.lock.RUnlock()
+
+	listener := `function() {
+	var ele = this;
+	ele.__autogcdContextMenu = false;
+	ele.__autogcdContextMenuListener = function() { ele.__autogcdContextMenu = true; };
+	ele.addEventListener('contextmenu', ele.__autogcdContextMenuListener, true);
+}`
+	if _, err := e.tab.callFunctionOnNode(id, listener); err != nil {
+		return err
+	}
+
+	if err := e.tab.ClickAt(float64(x), float64(y), ClickOptions{Button: "right"}); err != nil {
+		return err
+	}
+
+	dispatch := `function(x, y) {
+	this.removeEventListener('contextmenu', this.__autogcdContextMenuListener, true);
+	var received = this.__autogcdContextMenu;
+	delete this.__autogcdContextMenu;
+	delete this.__autogcdContextMenuListener;
+	if (!received) {
+		this.dispatchEvent(new MouseEvent('contextmenu', {bubbles: true, cancelable: true, view: window, button: 2, buttons: 2, clientX: x, clientY: y}));
+	}
+}`
+	_, err = e.tab.callFunctionOnNode(id, dispatch, x, y)
+	return err
+}
+
 // Double clicks the center of the element.
 func (e *Element) DoubleClick() error {
 	x, y, err := e.getCenter()
@@ -635,13 +983,95 @@ func (e *Element) Dimensions() ([]float64, error) {
 	return points, nil
 }
 
-// gets the center of the element
+// Scrolls the element to the center of the viewport so elements below the fold can be clicked.
+// Returns InvalidElementErr if the element has been removed from the DOM.
+func (e *Element) ScrollIntoView() error {
+	e.lock.RLock()
+	id := e.id
+	invalidated := e.invalidated
+	e.lock.RUnlock()
+
+	if invalidated {
+		return &InvalidElementErr{}
+	}
+
+	_, err := e.tab.callFunctionOnNode(id, "function() { this.scrollIntoView({block: 'center', inline: 'center'}); }")
+	return err
+}
+
+// Returns the element's border box in css pixels relative to the viewport. Returns InvalidDimensionsErr
+// if the element has no box model, such as when it is display:none, or InvalidElementErr if it has been
+// removed from the DOM.
+func (e *Element) GetBoundingBox() (*Rect, error) {
+	e.lock.RLock()
+	id := e.id
+	invalidated := e.invalidated
+	e.lock.RUnlock()
+
+	if invalidated {
+		return nil, &InvalidElementErr{}
+	}
+
+	box, err := e.tab.DOM.GetBoxModelWithParams(&gcdapi.DOMGetBoxModelParams{NodeId: id})
+	if err != nil {
+		return nil, &InvalidDimensionsErr{Message: "unable to get box model: " + err.Error()}
+	}
+
+	x, y, width, height, err := bounds(box.Border)
+	if err != nil {
+		return nil, err
+	}
+	return &Rect{X: x, Y: y, Width: width, Height: height}, nil
+}
+
+// Takes a png screenshot of just the element, clipped to its border box. The element is scrolled into
+// view first. Returns InvalidDimensionsErr if the element has no area or InvalidElementErr if it has
+// been removed from the DOM.
+func (e *Element) GetScreenShot() ([]byte, error) {
+	if err := e.ScrollIntoView(); err != nil {
+		return nil, err
+	}
+
+	rect, err := e.GetBoundingBox()
+	if err != nil {
+		return nil, err
+	}
+
+	if rect.Width == 0 || rect.Height == 0 {
+		return nil, &InvalidDimensionsErr{Message: "element has no visible area"}
+	}
+
+	// box model coordinates are relative to the viewport, clips are relative to the page.
+	layoutViewport, _, _, err := e.tab.Page.GetLayoutMetrics()
+	if err != nil {
+		return nil, err
+	}
+
+	clip := &gcdapi.PageViewport{X: rect.X + float64(layoutViewport.PageX), Y: rect.Y + float64(layoutViewport.PageY), Width: rect.Width, Height: rect.Height, Scale: 1}
+	return e.tab.captureScreenShot(clip)
+}
+
+// gets the center of the element's content box, returns InvalidElementErr if the element was removed
+// from the DOM or InvalidDimensionsErr if it has no area (hidden) so there is nothing to click on.
 func (e *Element) getCenter() (int, int, error) {
+	if e.IsInvalid() {
+		return 0, 0, &InvalidElementErr{}
+	}
+
 	points, err := e.Dimensions()
 	if err != nil {
 		return 0, 0, err
 	}
 
+	_, _, width, height, err := bounds(points)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if width == 0 || height == 0 {
+		return 0, 0, &InvalidDimensionsErr{Message: "element has no visible area"}
+	}
+
 	x, y, err := centroid(points)
 	if err != nil {
 		return 0, 0, err
@@ -649,8 +1079,9 @@ func (e *Element) getCenter() (int, int, error) {
 	return x, y, nil
 }
 
-// SendKeys - sends each individual character after focusing (clicking) on the element.
-// Extremely basic, doesn't take into account most/all system keys except enter, tab or backspace.
+// SendKeys - sends each individual character after focusing (clicking) on the element, see
+// Tab.SendKeys. Use \n for Enter, \b for backspace or \t for Tab, characters which need shift
+// on a US keyboard, such as uppercase letters, are sent with the shift modifier.
 func (e *Element) SendKeys(text string) error {
 	e.Focus()
 	err := e.Click()
@@ -695,3 +1126,20 @@ func centroid(points []float64) (int, int, error) {
 	}
 	return x / (pointLen / 2), y / (pointLen / 2), nil
 }
+
+// Returns the x, y, width and height of the rectangle bounding the points of a quad.
+func bounds(points []float64) (float64, float64, float64, float64, error) {
+	pointLen := len(points)
+	if pointLen == 0 || pointLen%2 != 0 {
+		return 0, 0, 0, 0, &InvalidDimensionsErr{"number of points are not divisible by two"}
+	}
+	minX, minY := points[0], points[1]
+	maxX, maxY := points[0], points[1]
+	for i := 2; i < pointLen; i = i + 2 {
+		minX = math.Min(minX, points[i])
+		maxX = math.Max(maxX, points[i])
+		minY = math.Min(minY, points[i+1])
+		maxY = math.Max(maxY, points[i+1])
+	}
+	return minX, minY, maxX - minX, maxY - minY, nil
+}
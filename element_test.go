@@ -1,6 +1,8 @@
 package autogcd
 
 import (
+	"bytes"
+	"image/png"
 	"sync"
 	"testing"
 	"time"
@@ -106,6 +108,57 @@ func TestElementClick(t *testing.T) {
 	timeout.Stop()
 }
 
+func TestElementClickInvalid(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "button.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, ElementsBySelectorNotEmpty(tab, "button"))
+	if err != nil {
+		t.Fatalf("error finding buttons, timed out waiting: %s\n", err)
+	}
+
+	buttons, err := tab.GetElementsBySelector("button")
+	if err != nil {
+		t.Fatalf("error finding buttons: %s\n", err)
+	}
+
+	if err := buttons[0].SetAttributeValue("style", "width: 0; height: 0; padding: 0; border: 0"); err != nil {
+		t.Fatalf("error setting style: %s\n", err)
+	}
+
+	if err := buttons[0].Click(); err == nil {
+		t.Fatalf("expected error clicking element with no area\n")
+	} else if _, ok := err.(*InvalidDimensionsErr); !ok {
+		t.Fatalf("expected InvalidDimensionsErr got: %T %s\n", err, err)
+	}
+
+	if _, err := tab.EvaluateScript("document.body.removeChild(document.getElementById('button2'))"); err != nil {
+		t.Fatalf("error removing button: %s\n", err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		return buttons[1].IsInvalid()
+	})
+	if err != nil {
+		t.Fatalf("expected removed button to be invalidated: %s\n", err)
+	}
+
+	if err := buttons[1].Click(); err == nil {
+		t.Fatalf("expected error clicking removed element\n")
+	} else if _, ok := err.(*InvalidElementErr); !ok {
+		t.Fatalf("expected InvalidElementErr got: %T %s\n", err, err)
+	}
+}
+
 func TestElementMouseOver(t *testing.T) {
 	testAuto := testDefaultStartup(t)
 	defer testAuto.Shutdown()
@@ -208,6 +261,227 @@ func TestElementDoubleClick(t *testing.T) {
 	timeout.Stop()
 }
 
+func TestElementDoubleClickEvents(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "dblclick.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	div, _, err := tab.GetElementById("doubleclick")
+	if err != nil {
+		t.Fatalf("error finding div: %s\n", err)
+	}
+
+	if err := div.DoubleClick(); err != nil {
+		t.Fatalf("error double clicking div: %s\n", err)
+	}
+
+	rro, err := tab.EvaluateScript("window.mouseEvents.join(',')")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "click:1,click:2,dblclick:2" {
+		t.Fatalf("expected the events of a genuine double click got: %v\n", rro.Value)
+	}
+}
+
+func TestElementScrollIntoView(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "scroll.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	button, _, err := tab.GetElementById("bottom")
+	if err != nil {
+		t.Fatalf("error getting button: %s\n", err)
+	}
+
+	if err := button.ScrollIntoView(); err != nil {
+		t.Fatalf("error scrolling into view: %s\n", err)
+	}
+
+	rro, err := tab.EvaluateScript("var r = document.getElementById('bottom').getBoundingClientRect(); r.top >= 0 && r.bottom <= window.innerHeight")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != true {
+		t.Fatalf("expected button to be within the viewport")
+	}
+}
+
+func TestElementGetBoundingBox(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "box.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	box, _, err := tab.GetElementById("box")
+	if err != nil {
+		t.Fatalf("error getting box: %s\n", err)
+	}
+
+	rect, err := box.GetBoundingBox()
+	if err != nil {
+		t.Fatalf("error getting bounding box: %s\n", err)
+	}
+
+	// the border box includes the 5px border on each side
+	if rect.X != 10 || rect.Y != 20 || rect.Width != 110 || rect.Height != 60 {
+		t.Fatalf("expected 10,20 110x60 got: %#v\n", rect)
+	}
+
+	hidden, _, err := tab.GetElementById("hidden")
+	if err != nil {
+		t.Fatalf("error getting hidden: %s\n", err)
+	}
+
+	if _, err := hidden.GetBoundingBox(); err == nil {
+		t.Fatalf("expected error getting bounding box of element which is not displayed")
+	} else if _, ok := err.(*InvalidDimensionsErr); !ok {
+		t.Fatalf("expected InvalidDimensionsErr got: %T %s\n", err, err)
+	}
+}
+
+func TestElementIsVisible(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "box.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	box, err := tab.WaitForElementBySelector("#box", testWaitTimeout)
+	if err != nil {
+		t.Fatalf("error finding box: %s\n", err)
+	}
+
+	if visible, err := box.IsVisible(); err != nil || !visible {
+		t.Fatalf("expected box to be visible got: %v %v\n", visible, err)
+	}
+
+	hidden, err := tab.WaitForElementBySelector("#hidden", testWaitTimeout)
+	if err != nil {
+		t.Fatalf("error finding hidden: %s\n", err)
+	}
+
+	if visible, err := hidden.IsVisible(); err != nil || visible {
+		t.Fatalf("expected hidden to not be visible got: %v %v\n", visible, err)
+	}
+}
+
+func TestElementGetScreenShot(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "big_body.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	divs, err := tab.GetElementsBySelector("div")
+	if err != nil || len(divs) != 2 {
+		t.Fatalf("error getting divs: %s\n", err)
+	}
+
+	// the last div is below the fold
+	imgBytes, err := divs[1].GetScreenShot()
+	if err != nil {
+		t.Fatalf("error taking screenshot of element: %s\n", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(imgBytes))
+	if err != nil {
+		t.Fatalf("error decoding screenshot: %s\n", err)
+	}
+
+	dimensions, err := divs[1].Dimensions()
+	if err != nil {
+		t.Fatalf("error getting dimensions: %s\n", err)
+	}
+
+	_, _, width, height, _ := bounds(dimensions)
+	if img.Bounds().Dx() < int(width) || img.Bounds().Dy() < int(height) {
+		t.Fatalf("expected screenshot of at least %fx%f got %dx%d\n", width, height, img.Bounds().Dx(), img.Bounds().Dy())
+	}
+
+	if err := divs[0].SetAttributeValue("style", "display: inline-block; width: 0; height: 0; overflow: hidden"); err != nil {
+		t.Fatalf("error setting style: %s\n", err)
+	}
+
+	if _, err := divs[0].GetScreenShot(); err == nil {
+		t.Fatalf("expected error taking screenshot of element with no area")
+	}
+}
+
+func TestElementContextMenu(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "contextmenu.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, ElementByIdReady(tab, "target"))
+	if err != nil {
+		t.Fatalf("error finding target, timed out waiting: %s\n", err)
+	}
+
+	ele, _, err := tab.GetElementById("target")
+	if err != nil {
+		t.Fatalf("error finding target: %s\n", err)
+	}
+
+	if err := ele.ContextMenu(); err != nil {
+		t.Fatalf("error opening context menu: %s\n", err)
+	}
+
+	rro, err := tab.EvaluateScript("window.menuCount + ',' + document.getElementById('menu').style.display")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "1,block" {
+		t.Fatalf("expected custom menu to be shown once got: %v\n", rro.Value)
+	}
+}
+
 func TestElementGetSource(t *testing.T) {
 	var ele []*Element
 	var src string
@@ -299,6 +573,237 @@ func TestElementGetAttributes(t *testing.T) {
 	if attrs["disabled"] != "" {
 		t.Fatalf("disabled attribute incorrect")
 	}
+
+	// the returned map is a copy
+	attrs["x"] = "modified"
+	if ele.GetAttribute("x") != "y" {
+		t.Fatalf("modifying returned attributes changed the element")
+	}
+
+	if _, err := tab.EvaluateScript("document.getElementById('attr').removeAttribute('x')"); err != nil {
+		t.Fatalf("error removing attribute: %s\n", err)
+	}
+
+	if ele.HasAttribute("x") {
+		t.Fatalf("removed attribute is still returned")
+	}
+
+	forms, err := tab.GetElementsBySelector("form")
+	if err != nil || len(forms) != 1 {
+		t.Fatalf("error getting form: %s\n", err)
+	}
+
+	attrs, err = forms[0].GetAttributes()
+	if err != nil {
+		t.Fatalf("error getting form attributes: %s\n", err)
+	}
+
+	if attrs == nil || len(attrs) != 0 {
+		t.Fatalf("expected empty attributes for form got: %v\n", attrs)
+	}
+}
+
+func TestElementGetText(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "nested.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	ele, err := tab.WaitForElementBySelector("#third", testWaitTimeout)
+	if err != nil {
+		t.Fatalf("error finding element: %s\n", err)
+	}
+
+	text, err := ele.GetText()
+	if err != nil {
+		t.Fatalf("error getting text: %s\n", err)
+	}
+
+	if text != "three" {
+		t.Fatalf("expected three got: %q\n", text)
+	}
+
+	if _, err := tab.EvaluateScript("document.getElementById('third').style.textTransform = 'uppercase'"); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	// innerText is the text as rendered
+	if text, err = ele.GetText(); err != nil || text != "THREE" {
+		t.Fatalf("expected THREE got: %q %v\n", text, err)
+	}
+
+	if _, err := tab.EvaluateScript("document.getElementById('third').remove()"); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		return ele.IsInvalid()
+	})
+	if err != nil {
+		t.Fatalf("expected removed element to be invalidated: %s\n", err)
+	}
+
+	if _, err := ele.GetText(); err == nil {
+		t.Fatalf("expected error getting text of removed element")
+	}
+}
+
+func TestElementGetTextMetrics(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "text.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	oneline, err := tab.WaitForElementBySelector("#oneline", testWaitTimeout)
+	if err != nil {
+		t.Fatalf("error finding element: %s\n", err)
+	}
+
+	metrics, err := oneline.GetTextMetrics()
+	if err != nil {
+		t.Fatalf("error getting text metrics: %s\n", err)
+	}
+
+	if metrics.Lines != 1 || metrics.Width <= 0 || metrics.Height <= 0 {
+		t.Fatalf("expected a single line with a size got: %#v\n", metrics)
+	}
+
+	wrapped, err := tab.WaitForElementBySelector("#wrapped", testWaitTimeout)
+	if err != nil {
+		t.Fatalf("error finding element: %s\n", err)
+	}
+
+	wrappedMetrics, err := wrapped.GetTextMetrics()
+	if err != nil {
+		t.Fatalf("error getting text metrics: %s\n", err)
+	}
+
+	if wrappedMetrics.Lines < 2 || wrappedMetrics.Width > 100 || wrappedMetrics.Height <= metrics.Height {
+		t.Fatalf("expected wrapped text within 100px got: %#v\n", wrappedMetrics)
+	}
+
+	empty, err := tab.WaitForElementBySelector("#empty", testWaitTimeout)
+	if err != nil {
+		t.Fatalf("error finding element: %s\n", err)
+	}
+
+	if _, err := empty.GetTextMetrics(); err == nil {
+		t.Fatalf("expected error measuring element without text")
+	} else if _, ok := err.(*ElementHasNoTextErr); !ok {
+		t.Fatalf("expected ElementHasNoTextErr got: %T %s\n", err, err)
+	}
+}
+
+func TestElementContains(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "attributes.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, ElementsBySelectorNotEmpty(tab, "form"))
+	if err != nil {
+		t.Fatalf("error finding form, timed out waiting: %s\n", err)
+	}
+
+	forms, err := tab.GetElementsBySelector("form")
+	if err != nil {
+		t.Fatalf("error finding form: %s\n", err)
+	}
+
+	contains, err := forms[0].Contains("input[name=attrtest]")
+	if err != nil {
+		t.Fatalf("error checking form contains input: %s\n", err)
+	}
+
+	if !contains {
+		t.Fatalf("expected form to contain input")
+	}
+
+	contains, err = forms[0].Contains("form")
+	if err != nil {
+		t.Fatalf("error checking form contains form: %s\n", err)
+	}
+
+	if contains {
+		t.Fatalf("form should not contain itself")
+	}
+}
+
+func TestElementGetChildrenAndParent(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "nested.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, ElementsBySelectorNotEmpty(tab, "ul"))
+	if err != nil {
+		t.Fatalf("error finding ul, timed out waiting: %s\n", err)
+	}
+
+	list, err := tab.GetElementBySelector("ul")
+	if err != nil {
+		t.Fatalf("error finding ul: %s\n", err)
+	}
+
+	children, err := list.GetChildren()
+	if err != nil {
+		t.Fatalf("error getting children: %s\n", err)
+	}
+
+	items := 0
+	for _, child := range children {
+		if err := child.WaitForReady(); err != nil {
+			t.Fatalf("error waiting for child: %s\n", err)
+		}
+		if tagName, _ := child.GetTagName(); tagName == "li" {
+			items++
+		}
+	}
+
+	if items != 3 {
+		t.Fatalf("expected 3 li children got: %d\n", items)
+	}
+
+	parent, err := list.GetParent()
+	if err != nil {
+		t.Fatalf("error getting parent: %s\n", err)
+	}
+
+	if err := parent.WaitForReady(); err != nil {
+		t.Fatalf("error waiting for parent: %s\n", err)
+	}
+
+	if id := parent.GetAttribute("id"); id != "second" {
+		t.Fatalf("expected parent to be #second got: %s\n", id)
+	}
 }
 
 func TestElementSetAttributeValue(t *testing.T) {
@@ -365,6 +870,68 @@ func TestElementSetAttributeValue(t *testing.T) {
 	}
 }
 
+func TestElementRemoveAttribute(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "attributes.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, ElementByIdReady(tab, "attr"))
+	if err != nil {
+		t.Fatalf("error finding attr, timed out waiting: %s\n", err)
+	}
+
+	ele, _, err := tab.GetElementById("attr")
+	if err != nil {
+		t.Fatalf("error finding input: %s %#v\n", err, ele)
+	}
+
+	if err := ele.RemoveAttribute("disabled"); err != nil {
+		t.Fatalf("error removing attribute: %s\n", err)
+	}
+
+	if ele.HasAttribute("disabled") {
+		t.Fatalf("disabled attribute was not removed")
+	}
+
+	rro, err := tab.EvaluateScript("document.getElementById('attr').disabled")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != false {
+		t.Fatalf("expected input to be enabled got: %v\n", rro.Value)
+	}
+
+	if _, err := tab.EvaluateScript("document.forms[0].removeChild(document.getElementById('attr'))"); err != nil {
+		t.Fatalf("error removing input: %s\n", err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		return ele.IsInvalid()
+	})
+	if err != nil {
+		t.Fatalf("expected removed input to be invalidated: %s\n", err)
+	}
+
+	if err := ele.RemoveAttribute("x"); err == nil {
+		t.Fatalf("expected error removing attribute of removed element")
+	} else if _, ok := err.(*InvalidElementErr); !ok {
+		t.Fatalf("expected InvalidElementErr got: %T %s\n", err, err)
+	}
+
+	if err := ele.SetAttributeValue("x", "z"); err == nil {
+		t.Fatalf("expected error setting attribute of removed element")
+	}
+}
+
 func TestElementSendKeys(t *testing.T) {
 	var err error
 	var ele *Element
@@ -411,6 +978,96 @@ func TestElementSendKeys(t *testing.T) {
 	wg.Wait()
 }
 
+func TestElementSendKeysShift(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "input.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, ElementByIdReady(tab, "attr"))
+	if err != nil {
+		t.Fatalf("error finding attr, timed out waiting: %s\n", err)
+	}
+
+	ele, _, err := tab.GetElementById("attr")
+	if err != nil {
+		t.Fatalf("error finding input attr: %s\n", err)
+	}
+
+	script := `window.keys = [];
+document.getElementById('attr').addEventListener('keydown', function(e) {
+	window.keys.push(e.key + ':' + e.code + (e.shiftKey ? ':shift' : ''));
+});`
+	if _, err := tab.EvaluateScript(script); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	text := "aB3!{ }"
+	if err := ele.SendKeys(text); err != nil {
+		t.Fatalf("error sending keys: %s\n", err)
+	}
+
+	rro, err := tab.EvaluateScript("document.getElementById('attr').value + '|' + window.keys.join(',')")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	expected := text + "|a:KeyA,B:KeyB:shift,3:Digit3,!:Digit1:shift,{:BracketLeft:shift, :Space,}:BracketRight:shift"
+	if rro.Value != expected {
+		t.Fatalf("expected %s got %v\n", expected, rro.Value)
+	}
+}
+
+func TestElementSetContentEditableText(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "contenteditable.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	editor, err := tab.WaitForElementBySelector("#editor", testWaitTimeout)
+	if err != nil {
+		t.Fatalf("error finding editor: %s\n", err)
+	}
+
+	if err := editor.SetContentEditableText("rich text"); err != nil {
+		t.Fatalf("error setting text: %s\n", err)
+	}
+
+	rro, err := tab.EvaluateScript("document.getElementById('editor').textContent + '|' + window.inputEvents.join(',')")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "rich text|beforeinput:rich text,input:rich text" {
+		t.Fatalf("expected text to be set and events fired got: %v\n", rro.Value)
+	}
+
+	readonly, err := tab.WaitForElementBySelector("#readonly", testWaitTimeout)
+	if err != nil {
+		t.Fatalf("error finding readonly: %s\n", err)
+	}
+
+	if err := readonly.SetContentEditableText("text"); err == nil {
+		t.Fatalf("expected error setting text of element which is not editable")
+	} else if _, ok := err.(*IncorrectElementTypeErr); !ok {
+		t.Fatalf("expected IncorrectElementTypeErr got: %T %s\n", err, err)
+	}
+}
+
 func TestElementGetTag(t *testing.T) {
 	var err error
 	var ele *Element
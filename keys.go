@@ -0,0 +1,114 @@
+/*
+The MIT License (MIT)
+
+Copyright (c) 2017 isaac dawson
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package autogcd
+
+import (
+	"github.com/wirepair/gcd/gcdapi"
+)
+
+// A key on a US keyboard layout
+type keyDefinition struct {
+	key     string // value of KeyboardEvent.key, the character typed or the name of the key
+	code    string // value of KeyboardEvent.code, the physical key pressed
+	keyCode int    // windows virtual key code
+	shift   bool   // shift has to be held to type the character
+}
+
+// Keys which are not letters or digits, the characters typed with shift share the code of their key.
+var keyDefinitions = map[rune]keyDefinition{
+	'\b': {"Backspace", "Backspace", 8, false},
+	'\t': {"Tab", "Tab", 9, false},
+	'\r': {"Enter", "Enter", 13, false},
+	'\n': {"Enter", "Enter", 13, false},
+	' ':  {" ", "Space", 32, false},
+	')':  {")", "Digit0", 48, true},
+	'!':  {"!", "Digit1", 49, true},
+	'@':  {"@", "Digit2", 50, true},
+	'#':  {"#", "Digit3", 51, true},
+	'$':  {"$", "Digit4", 52, true},
+	'%':  {"%", "Digit5", 53, true},
+	'^':  {"^", "Digit6", 54, true},
+	'&':  {"&", "Digit7", 55, true},
+	'*':  {"*", "Digit8", 56, true},
+	'(':  {"(", "Digit9", 57, true},
+	';':  {";", "Semicolon", 186, false},
+	':':  {":", "Semicolon", 186, true},
+	'=':  {"=", "Equal", 187, false},
+	'+':  {"+", "Equal", 187, true},
+	',':  {",", "Comma", 188, false},
+	'<':  {"<", "Comma", 188, true},
+	'-':  {"-", "Minus", 189, false},
+	'_':  {"_", "Minus", 189, true},
+	'.':  {".", "Period", 190, false},
+	'>':  {">", "Period", 190, true},
+	'/':  {"/", "Slash", 191, false},
+	'?':  {"?", "Slash", 191, true},
+	'`':  {"`", "Backquote", 192, false},
+	'~':  {"~", "Backquote", 192, true},
+	'[':  {"[", "BracketLeft", 219, false},
+	'{':  {"{", "BracketLeft", 219, true},
+	'\\': {"\\", "Backslash", 220, false},
+	'|':  {"|", "Backslash", 220, true},
+	']':  {"]", "BracketRight", 221, false},
+	'}':  {"}", "BracketRight", 221, true},
+	'\'': {"'", "Quote", 222, false},
+	'"':  {"\"", "Quote", 222, true},
+}
+
+// Returns the key event parameters to type char, the type of event is left for the caller to set.
+// Characters not on a US keyboard are sent as text only.
+func keyEventParams(char rune) *gcdapi.InputDispatchKeyEventParams {
+	params := &gcdapi.InputDispatchKeyEventParams{Text: string(char), UnmodifiedText: string(char)}
+
+	def, ok := keyDefinitions[char]
+	switch {
+	case ok:
+	case char >= 'a' && char <= 'z':
+		def = keyDefinition{string(char), "Key" + string(char-'a'+'A'), int(char - 'a' + 'A'), false}
+	case char >= 'A' && char <= 'Z':
+		def = keyDefinition{string(char), "Key" + string(char), int(char), true}
+		params.UnmodifiedText = string(char - 'A' + 'a')
+	case char >= '0' && char <= '9':
+		def = keyDefinition{string(char), "Digit" + string(char), int(char), false}
+	default:
+		params.Key = string(char)
+		return params
+	}
+
+	// enter is sent as a carriage return, so \n and \r both submit forms.
+	if def.keyCode == 13 {
+		params.Text = "\r"
+		params.UnmodifiedText = "\r"
+	}
+
+	if def.shift {
+		params.Modifiers = 8
+	}
+	params.Key = def.key
+	params.Code = def.code
+	params.WindowsVirtualKeyCode = def.keyCode
+	params.NativeVirtualKeyCode = def.keyCode
+	return params
+}
@@ -26,10 +26,16 @@ package autogcd
 
 import (
 	"bytes"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -45,6 +51,19 @@ const maximumTotalBufferSize = 100 * 1000 * 1000
 // 10MB
 const maximumResourceBufferSize = 10 * 1000 * 1000
 
+// how many DOM change events GetDOMChangesChannel buffers before dropping them
+const domChangeBufferSize = 1024
+
+// how many node change events from the DOM subscribers are buffered while the tab is busy handling
+// an earlier change, large documents can send a burst of setChildNodes events.
+const nodeChangeBufferSize = 1024
+
+// how long a finished request id is remembered so a late requestWillBeSent for it is not tracked as in flight
+const finishedRequestTTL = 10 * time.Second
+
+// Where Tab.InjectjQuery loads jQuery from unless changed with Tab.SetJQueryUrl
+const defaultJQueryUrl = "https://code.jquery.com/jquery-3.3.1.min.js"
+
 // When we are unable to find an element/nodeId
 type ElementNotFoundErr struct {
 	Message string
@@ -72,6 +91,56 @@ func (e *InvalidNavigationErr) Error() string {
 	return e.Message
 }
 
+// When we are unable to access a frame
+type InvalidFrameErr struct {
+	Message string
+}
+
+func (e *InvalidFrameErr) Error() string {
+	return "Unable to access frame: " + e.Message
+}
+
+// When we are unable to find a parsed script
+type ScriptNotFoundErr struct {
+	Message string
+}
+
+func (e *ScriptNotFoundErr) Error() string {
+	return "Unable to find script " + e.Message
+}
+
+// Returned when more than one operation failed, holding each of the errors
+type AggregateErr struct {
+	Message string
+	Errors  []error
+}
+
+func (e *AggregateErr) Error() string {
+	errs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err.Error()
+	}
+	return e.Message + ": " + strings.Join(errs, "; ")
+}
+
+// Returned when the page was not served with a certificate
+type CertificateNotFoundErr struct {
+	Message string
+}
+
+func (e *CertificateNotFoundErr) Error() string {
+	return "Unable to find certificate: " + e.Message
+}
+
+// Returned when an unknown device preset is requested
+type UnknownDeviceErr struct {
+	Message string
+}
+
+func (e *UnknownDeviceErr) Error() string {
+	return "Unknown device: " + e.Message
+}
+
 // Returned when an injected script caused an error
 type ScriptEvaluationErr struct {
 	Message          string
@@ -105,12 +174,21 @@ type PromptHandlerFunc func(tab *Tab, message, promptType string)
 // A function for handling console messages
 type ConsoleMessageFunc func(tab *Tab, message *gcdapi.ConsoleConsoleMessage)
 
+// A function for handling console API calls with their raw argument objects and stack trace
+type ConsoleAPIFunc func(tab *Tab, level string, args []*gcdapi.RuntimeRemoteObject, stackTrace *gcdapi.RuntimeStackTrace)
+
+// A function for handling uncaught javascript exceptions thrown by the page
+type RuntimeExceptionFunc func(tab *Tab, exception *gcdapi.RuntimeExceptionDetails)
+
 // A function for handling network requests
 type NetworkRequestHandlerFunc func(tab *Tab, request *NetworkRequest)
 
 // A function for handling network responses
 type NetworkResponseHandlerFunc func(tab *Tab, response *NetworkResponse)
 
+// A function for handling intercepted requests, which must be continued or aborted
+type RequestInterceptedFunc func(tab *Tab, event *gcdapi.NetworkRequestInterceptedEvent)
+
 // A function for handling network finished, meaning it's safe to call Network.GetResponseBody
 type NetworkFinishedHandlerFunc func(tab *Tab, requestId string, dataLength, timeStamp float64)
 
@@ -120,32 +198,74 @@ type StorageFunc func(tab *Tab, eventType string, eventDetails *StorageEvent)
 // A function to listen for DOM Node Change Events
 type DomChangeHandlerFunc func(tab *Tab, change *NodeChangeEvent)
 
+// A function for handling the debugger pausing script execution, call Tab.Resume to continue.
+type DebuggerPausedFunc func(tab *Tab, event *gcdapi.DebuggerPausedEvent)
+
+// A function for handling performance timeline events, such as largest contentful paints or layout shifts
+type TimelineEventFunc func(tab *Tab, event *TimelineEvent)
+
 // A function to iteratively call until returns without error
 type ConditionalFunc func(tab *Tab) bool
 
 // Our tab object for driving a specific tab and gathering elements.
 type Tab struct {
-	*gcd.ChromeTarget                            // underlying chrometarget
-	eleMutex              *sync.RWMutex          // locks our elements when added/removed.
-	elements              map[int]*Element       // our map of elements for this tab
-	topNodeId             atomic.Value           // the nodeId of the current top level #document
-	topFrameId            atomic.Value           // the frameId of the current top level #document
-	isNavigatingFlag      atomic.Value           // are we currently navigating (between Page.Navigate -> page.loadEventFired)
-	isTransitioningFlag   atomic.Value           // has navigation occurred on the top frame (not due to Navigate() being called)
-	debug                 bool                   // for debug printing
-	nodeChange            chan *NodeChangeEvent  // for receiving node change events from tab_subscribers
-	navigationCh          chan int               // for receiving navigation complete messages while isNavigating is true
-	docUpdateCh           chan struct{}          // for receiving document update completion while isNavigating is true
-	crashedCh             chan string            // the chrome tab crashed with a reason
-	exitCh                chan struct{}          // for when we close the tab, kill go routines
-	shutdown              atomic.Value           // have we already shut down
-	disconnectedHandler   TabDisconnectedHandler // called with reason the chrome tab was disconnected from the debugger service
-	navigationTimeout     time.Duration          // amount of time to wait before failing navigation
-	elementTimeout        time.Duration          // amount of time to wait for element readiness
-	stabilityTimeout      time.Duration          // amount of time to give up waiting for stability
-	stableAfter           time.Duration          // amount of time of no activity to consider the DOM stable
-	lastNodeChangeTimeVal atomic.Value           // timestamp of when the last node change occurred atomic because multiple go routines will modify
-	domChangeHandler      DomChangeHandlerFunc   // allows the caller to be notified of DOM change events.
+	*gcd.ChromeTarget                                               // underlying chrometarget
+	eleMutex              *sync.RWMutex                             // locks our elements when added/removed.
+	elements              map[int]*Element                          // our map of elements for this tab
+	topNodeId             atomic.Value                              // the nodeId of the current top level #document
+	topFrameId            atomic.Value                              // the frameId of the current top level #document
+	isNavigatingFlag      atomic.Value                              // are we currently navigating (between Page.Navigate -> page.loadEventFired)
+	isTransitioningFlag   atomic.Value                              // has navigation occurred on the top frame (not due to Navigate() being called)
+	debug                 bool                                      // for debug printing
+	logger                *log.Logger                               // where debug output is written, the standard logger if nil
+	nodeChange            chan *NodeChangeEvent                     // for receiving node change events from tab_subscribers
+	navigationCh          chan int                                  // for receiving navigation complete messages while isNavigating is true
+	docUpdateCh           chan struct{}                             // for receiving document update completion while isNavigating is true
+	transitionCh          chan struct{}                             // for receiving the top frame finished loading while isTransitioning is true
+	stopLoadingCh         chan struct{}                             // for aborting the wait of Navigate or Reload when StopLoading is called
+	crashedCh             chan string                               // the chrome tab crashed with a reason
+	exitCh                chan struct{}                             // for when we close the tab, kill go routines
+	shutdown              atomic.Value                              // have we already shut down
+	disconnectedHandler   TabDisconnectedHandler                    // called with reason the chrome tab was disconnected from the debugger service
+	navigationTimeout     time.Duration                             // amount of time to wait before failing navigation
+	elementTimeout        time.Duration                             // amount of time to wait for element readiness
+	jQueryUrl             string                                    // where InjectjQuery loads jQuery from
+	stabilityTimeout      time.Duration                             // amount of time to give up waiting for stability
+	stableAfter           time.Duration                             // amount of time of no activity to consider the DOM stable
+	lastNodeChangeTimeVal atomic.Value                              // timestamp of when the last node change occurred atomic because multiple go routines will modify
+	domChangeHandler      DomChangeHandlerFunc                      // allows the caller to be notified of DOM change events.
+	domChangeMutex        *sync.Mutex                               // locks domChangeCh
+	domChangeCh           chan *NodeChangeEvent                     // delivers DOM change events to the caller, nil if not requested
+	networkMutex          *sync.RWMutex                             // locks our network handlers and tracked requests.
+	networkEnabled        bool                                      // has the Network debugger service been enabled
+	requests              map[string]*NetworkRequest                // requests that have been sent but have not finished or failed
+	finishedRequests      map[string]time.Time                      // recently finished or failed request ids, events may arrive out of order
+	requestHandler        NetworkRequestHandlerFunc                 // caller's handler for outbound network requests
+	responseHandler       NetworkResponseHandlerFunc                // caller's handler for inbound network responses
+	finishedHandler       NetworkFinishedHandlerFunc                // caller's handler for finished network requests
+	bodyPattern           *regexp.Regexp                            // urls of responses whose bodies we collect, nil if not collecting
+	collectedBodies       map[string][]byte                         // url to response body of collected responses
+	acceptLanguage        string                                    // Accept-Language header to send, re-applied when the network is enabled
+	interceptMutex        *sync.RWMutex                             // locks our request interception state
+	interceptEnabled      bool                                      // are requests currently being intercepted
+	proxyUsername         string                                    // username to answer proxy auth challenges with
+	proxyPassword         string                                    // password to answer proxy auth challenges with
+	interceptHandler      RequestInterceptedFunc                    // caller's handler for intercepted requests
+	interceptPatterns     []*InterceptPattern                       // requests the caller wishes to intercept
+	languageScriptId      string                                    // identifier of the injected navigator.language override
+	contextMutex          *sync.RWMutex                             // locks our frame execution contexts.
+	frameContexts         map[string]int                            // frameId to the frame's default execution context id
+	scriptMutex           *sync.RWMutex                             // locks our parsed scripts.
+	scripts               map[string]string                         // scriptId to url of scripts parsed by the debugger
+	scriptOrder           []string                                  // scriptIds in the order they were parsed
+	postMessageMutex      *sync.Mutex                               // locks our post message listener script id
+	securityMutex         *sync.RWMutex                             // locks our security state
+	securityEnabled       bool                                      // has the Security debugger service been enabled
+	securityState         *gcdapi.SecuritySecurityStateChangedEvent // the latest security state of the page
+	emulationMutex        *sync.Mutex                               // locks our emulation state
+	mediaFeatures         map[string]string                         // emulated css media features, name to value
+	emulatedDevice        *Device                                   // device set by EmulateDevice, nil if not emulating one
+	postMessageScriptId   string                                    // identifier of the injected post message listener
 }
 
 // Creates a new tab using the underlying ChromeTarget
@@ -153,39 +273,85 @@ func open(target *gcd.ChromeTarget) (*Tab, error) {
 	t := &Tab{ChromeTarget: target}
 	t.eleMutex = &sync.RWMutex{}
 	t.elements = make(map[int]*Element)
-	t.nodeChange = make(chan *NodeChangeEvent)
-	t.navigationCh = make(chan int, 1)  // for signaling navigation complete
-	t.docUpdateCh = make(chan struct{}) // wait for documentUpdate to be called during navigation
-	t.crashedCh = make(chan string)     // reason the tab crashed/was disconnected.
+	t.nodeChange = make(chan *NodeChangeEvent, nodeChangeBufferSize)
+	t.navigationCh = make(chan int, 1)       // for signaling navigation complete
+	t.docUpdateCh = make(chan struct{}, 1)   // wait for documentUpdate to be called during navigation
+	t.stopLoadingCh = make(chan struct{}, 1) // abort waiting for navigation
+	t.transitionCh = make(chan struct{}, 1)  // signal top frame finished loading outside of Navigate
+	t.crashedCh = make(chan string)          // reason the tab crashed/was disconnected.
 	t.exitCh = make(chan struct{})
 	t.navigationTimeout = 30 * time.Second // default 30 seconds for timeout
 	t.elementTimeout = 5 * time.Second     // default 5 seconds for waiting for element.
 	t.stabilityTimeout = 2 * time.Second   // default 2 seconds before we give up waiting for stability
 	t.stableAfter = 300 * time.Millisecond // default 300 ms for considering the DOM stable
+	t.jQueryUrl = defaultJQueryUrl
 	t.domChangeHandler = nil
+	t.domChangeMutex = &sync.Mutex{}
+	t.networkMutex = &sync.RWMutex{}
+	t.requests = make(map[string]*NetworkRequest)
+	t.finishedRequests = make(map[string]time.Time)
+	t.collectedBodies = make(map[string][]byte)
+	t.interceptMutex = &sync.RWMutex{}
+	t.contextMutex = &sync.RWMutex{}
+	t.frameContexts = make(map[string]int)
+	t.scriptMutex = &sync.RWMutex{}
+	t.scripts = make(map[string]string)
+	t.postMessageMutex = &sync.Mutex{}
+	t.securityMutex = &sync.RWMutex{}
+	t.emulationMutex = &sync.Mutex{}
+	t.mediaFeatures = make(map[string]string)
 
 	// enable various debugger services
 	if _, err := t.Page.Enable(); err != nil {
-		return nil, err
+		return nil, &InvalidTabErr{Message: "unable to enable Page domain: " + err.Error()}
 	}
 
 	if _, err := t.DOM.Enable(); err != nil {
-		return nil, err
+		return nil, &InvalidTabErr{Message: "unable to enable DOM domain: " + err.Error()}
 	}
 
 	if _, err := t.Console.Enable(); err != nil {
-		return nil, err
+		return nil, &InvalidTabErr{Message: "unable to enable Console domain: " + err.Error()}
 	}
 
-	if _, err := t.Debugger.Enable(); err != nil {
-		return nil, err
-	}
 	t.disconnectedHandler = t.defaultDisconnectedHandler
 	t.subscribeEvents()
+
+	// enable runtime and debugger after subscribing so we are notified of existing
+	// execution contexts and parsed scripts
+	if _, err := t.Runtime.Enable(); err != nil {
+		return nil, &InvalidTabErr{Message: "unable to enable Runtime domain: " + err.Error()}
+	}
+
+	if _, err := t.Debugger.Enable(); err != nil {
+		return nil, &InvalidTabErr{Message: "unable to enable Debugger domain: " + err.Error()}
+	}
 	go t.listenDebuggerEvents()
 	return t, nil
 }
 
+// Detaches the tab from the debugger's events: every event subscription the tab registered is removed,
+// the DOM change channel is closed, background go routines exit and all known elements are invalidated.
+// The event channels the subscribers write to are not closed, an event already in flight could otherwise
+// panic sending on them, instead waiters are released by the tab's exit signal. The chrome tab itself is
+// left open, use AutoGcd.CloseTab to also close it. Safe to call more than once.
+func (t *Tab) Close() error {
+	t.close() // kill listening go routines
+
+	for _, method := range tabEvents {
+		t.Unsubscribe(method)
+	}
+	t.StopDOMChanges()
+
+	t.eleMutex.Lock()
+	for _, ele := range t.elements {
+		ele.setInvalidated(true)
+	}
+	t.elements = make(map[int]*Element)
+	t.eleMutex.Unlock()
+	return nil
+}
+
 // close our exitch.
 func (t *Tab) close() {
 	if !t.IsShuttingDown() {
@@ -206,11 +372,19 @@ func (t *Tab) setShutdownState(val bool) {
 	t.shutdown.Store(val)
 }
 
-// Enable or disable internal debug printing
+// Enable or disable internal debug printing, output is written to the standard logger unless
+// a logger was provided with SetDebugLogger.
 func (t *Tab) Debug(enabled bool) {
 	t.debug = enabled
 }
 
+// Writes internal debug output to logger instead of the standard logger and enables it. Passing
+// nil disables debug output, which is the default.
+func (t *Tab) SetDebugLogger(logger *log.Logger) {
+	t.logger = logger
+	t.debug = logger != nil
+}
+
 // Set the disconnected handler so caller can trap when the debugger was disconnected/crashed.
 func (t *Tab) SetDisconnectedHandler(handlerFn TabDisconnectedHandler) {
 	t.disconnectedHandler = handlerFn
@@ -230,6 +404,12 @@ func (t *Tab) SetElementWaitTimeout(timeout time.Duration) {
 	t.elementTimeout = timeout
 }
 
+// Where InjectjQuery loads jQuery from, point it at a local copy for pages without internet access.
+// The default is the jQuery 3.3.1 CDN.
+func (t *Tab) SetJQueryUrl(url string) {
+	t.jQueryUrl = url
+}
+
 // How long to wait for WaitStable() to return, default is 2 seconds.
 func (t *Tab) SetStabilityTimeout(timeout time.Duration) {
 	t.stabilityTimeout = timeout
@@ -293,10 +473,16 @@ func (t *Tab) GetTopNodeId() int {
 }
 
 // Navigates to a URL and does not return until the Page.loadEventFired event
-// as well as all setChildNode events have completed.
+// as well as all setChildNode events have completed or the navigation timeout is hit.
 // If successful, returns frameId.
 // If failed, returns frameId, friendly error text, and the error.
 func (t *Tab) Navigate(url string) (string, string, error) {
+	return t.NavigateWithTimeout(url, t.navigationTimeout)
+}
+
+// Same as Navigate but waits up to timeout instead of the tab's navigation timeout, returning a
+// TimeoutErr if the page does not finish loading in time. A timeout of zero or less waits indefinitely.
+func (t *Tab) NavigateWithTimeout(url string, timeout time.Duration) (string, string, error) {
 
 	if t.IsNavigating() {
 		return "", "", &InvalidNavigationErr{Message: "Unable to navigate, already navigating."}
@@ -308,13 +494,16 @@ func (t *Tab) Navigate(url string) (string, string, error) {
 		t.setIsNavigating(false)
 	}()
 
+	// drop any signals left over from a previous navigation that timed out
+	t.drainNavigationSignals()
+
 	frameId, errorText, err := t.Page.Navigate(url, "", "typed")
 	if err != nil {
 		return "", errorText, err
 	}
 	t.lastNodeChangeTimeVal.Store(time.Now())
 
-	err = t.readyWait(url)
+	err = t.readyWait(url, timeout)
 	if err != nil {
 		return frameId, "", err
 	}
@@ -322,6 +511,38 @@ func (t *Tab) Navigate(url string) (string, string, error) {
 	return frameId, "", err
 }
 
+// Loads html into the tab without a server, for testing a static snippet. Navigates to about:blank then
+// replaces the top frame's document with html, returning once the document's readyState is complete or
+// a TimeoutErr after the navigation timeout. Returns InvalidFrameErr if the top frame can not be resolved.
+func (t *Tab) SetContent(html string) error {
+	if _, _, err := t.Navigate("about:blank"); err != nil {
+		return err
+	}
+
+	resources, err := t.Page.GetResourceTree()
+	if err != nil {
+		return &InvalidFrameErr{Message: "unable to resolve the top frame: " + err.Error()}
+	}
+
+	if resources == nil || resources.Frame == nil {
+		return &InvalidFrameErr{Message: "unable to resolve the top frame"}
+	}
+
+	if _, err := t.Page.SetDocumentContent(resources.Frame.Id, html); err != nil {
+		return err
+	}
+	t.lastNodeChangeTimeVal.Store(time.Now())
+
+	err = t.WaitFor(50*time.Millisecond, t.navigationTimeout, func(tab *Tab) bool {
+		rro, err := tab.EvaluateScript("document.readyState")
+		return err == nil && rro.Value == "complete"
+	})
+	if err != nil {
+		return &TimeoutErr{Message: "waiting for content to be set"}
+	}
+	return nil
+}
+
 // An undocumented method of determining if chromium failed to load
 // a page due to DNS or connection timeouts.
 func (t *Tab) DidNavigationFail() (bool, string) {
@@ -342,10 +563,28 @@ func (t *Tab) DidNavigationFail() (bool, string) {
 // navigationCh waits for a Page.loadEventFired or timeout.
 // docUpdateCh waits for document updated event from Tab.documentUpdated
 // event processing to finish so we have a valid set of elements.
-func (t *Tab) readyWait(url string) error {
+// A timeout of zero or less waits until both have been received.
+func (t *Tab) readyWait(url string, timeout time.Duration) error {
+	return t.readyWaitOr(url, timeout, nil)
+}
+
+// Like readyWait but also returns once doneFn, if not nil, returns true. doneFn is polled
+// for navigations which may not load a new document, such as same-document history entries.
+func (t *Tab) readyWaitOr(url string, timeout time.Duration, doneFn func() bool) error {
 	var navigated bool
-	timeoutTimer := time.NewTimer(t.navigationTimeout)
-	defer timeoutTimer.Stop()
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		timeoutTimer := time.NewTimer(timeout)
+		defer timeoutTimer.Stop()
+		timeoutCh = timeoutTimer.C
+	}
+
+	var pollCh <-chan time.Time
+	if doneFn != nil {
+		pollTicker := time.NewTicker(100 * time.Millisecond)
+		defer pollTicker.Stop()
+		pollCh = pollTicker.C
+	}
 
 	for {
 		select {
@@ -353,7 +592,13 @@ func (t *Tab) readyWait(url string) error {
 			navigated = true
 		case <-t.docUpdateCh:
 			return nil
-		case <-timeoutTimer.C:
+		case <-pollCh:
+			if doneFn() {
+				return nil
+			}
+		case <-t.stopLoadingCh:
+			return &InvalidNavigationErr{Message: "loading was stopped for: " + url}
+		case <-timeoutCh:
 			msg := "navigating to: "
 			if navigated == true {
 				msg = "waiting for document updated failed for: "
@@ -363,27 +608,104 @@ func (t *Tab) readyWait(url string) error {
 	}
 }
 
+// Empties the navigation and document update channels of signals which were sent for a navigation
+// that has already returned.
+func (t *Tab) drainNavigationSignals() {
+	for {
+		select {
+		case <-t.navigationCh:
+		case <-t.docUpdateCh:
+		case <-t.stopLoadingCh:
+		default:
+			return
+		}
+	}
+}
+
 // Returns the current navigation index, history entries or error
 func (t *Tab) NavigationHistory() (int, []*gcdapi.PageNavigationEntry, error) {
 	return t.Page.GetNavigationHistory()
 }
 
 // Reloads the page injecting evalScript to run on load. set ignoreCache to true
-// to have it act like ctrl+f5.
+// to have it act like ctrl+f5. Like Navigate, does not return until the document
+// has been reloaded or the navigation timeout is hit.
 func (t *Tab) Reload(ignoreCache bool, evalScript string) error {
-	_, err := t.Page.Reload(ignoreCache, evalScript)
-	return err
+	if t.IsNavigating() {
+		return &InvalidNavigationErr{Message: "Unable to reload, already navigating."}
+	}
+	t.setIsNavigating(true)
+	defer t.setIsNavigating(false)
+
+	t.drainNavigationSignals()
+
+	url, _ := t.GetCurrentUrl()
+	t.debugf("reloading %s", url)
+	if _, err := t.Page.Reload(ignoreCache, evalScript); err != nil {
+		return err
+	}
+	t.lastNodeChangeTimeVal.Store(time.Now())
+
+	return t.readyWait(url, t.navigationTimeout)
+}
+
+// Stops loading the page, such as a navigation which is hanging. A Navigate or Reload
+// waiting for the page to load returns an InvalidNavigationErr.
+func (t *Tab) StopLoading() error {
+	if _, err := t.Page.StopLoading(); err != nil {
+		return err
+	}
+
+	if t.IsNavigating() {
+		select {
+		case t.stopLoadingCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Runs trigger, such as clicking a link or a script setting location, and waits for the navigation of
+// the top frame it starts to finish loading. Navigations which finished before trigger is called are
+// ignored, while one finishing before trigger returns is not missed. Returns a TimeoutErr if the top
+// frame does not finish loading before timeout.
+func (t *Tab) WaitForNavigation(trigger func() error, timeout time.Duration) error {
+	if t.IsNavigating() {
+		return &InvalidNavigationErr{Message: "Unable to wait for navigation, already navigating."}
+	}
+
+	select {
+	case <-t.transitionCh:
+	default:
+	}
+
+	if err := trigger(); err != nil {
+		return err
+	}
+
+	timeoutTimer := time.NewTimer(timeout)
+	defer timeoutTimer.Stop()
+
+	select {
+	case <-t.transitionCh:
+		t.lastNodeChangeTimeVal.Store(time.Now())
+		return nil
+	case <-t.exitCh:
+		return &InvalidTabErr{Message: "tab closed while waiting for navigation"}
+	case <-timeoutTimer.C:
+		return &TimeoutErr{Message: "waiting for navigation of the top frame"}
+	}
 }
 
-// Looks up the next navigation entry from the history and navigates to it.
+// Looks up the next navigation entry from the history and navigates to it. Like Navigate,
+// does not return until the page has loaded or the navigation timeout is hit.
 // Returns error if we could not find the next entry or navigation failed
 func (t *Tab) Forward() error {
 	next, err := t.ForwardEntry()
 	if err != nil {
 		return err
 	}
-	_, err = t.Page.NavigateToHistoryEntry(next.Id)
-	return err
+	return t.navigateToHistoryEntry(next)
 }
 
 // Returns the next entry in our navigation history for this tab.
@@ -392,23 +714,21 @@ func (t *Tab) ForwardEntry() (*gcdapi.PageNavigationEntry, error) {
 	if err != nil {
 		return nil, err
 	}
-	for i := 0; i < len(entries); i++ {
-		if idx < entries[i].Id {
-			return entries[i], nil
-		}
+	if idx < 0 || idx+1 >= len(entries) {
+		return nil, &InvalidNavigationErr{Message: "Unable to navigate forward as we are on the latest navigation entry"}
 	}
-	return nil, &InvalidNavigationErr{Message: "Unable to navigate forward as we are on the latest navigation entry"}
+	return entries[idx+1], nil
 }
 
-// Looks up the previous navigation entry from the history and navigates to it.
+// Looks up the previous navigation entry from the history and navigates to it. Like Navigate,
+// does not return until the page has loaded or the navigation timeout is hit.
 // Returns error if we could not find the previous entry or navigation failed
 func (t *Tab) Back() error {
 	prev, err := t.BackEntry()
 	if err != nil {
 		return err
 	}
-	_, err = t.Page.NavigateToHistoryEntry(prev.Id)
-	return err
+	return t.navigateToHistoryEntry(prev)
 }
 
 // Returns the previous entry in our navigation history for this tab.
@@ -417,12 +737,48 @@ func (t *Tab) BackEntry() (*gcdapi.PageNavigationEntry, error) {
 	if err != nil {
 		return nil, err
 	}
-	for i := len(entries); i > 0; i-- {
-		if idx < entries[i].Id {
-			return entries[i], nil
+	if idx <= 0 || idx > len(entries) {
+		return nil, &InvalidNavigationErr{Message: "Unable to navigate backward as we are on the first navigation entry"}
+	}
+	return entries[idx-1], nil
+}
+
+// navigates to the history entry and waits for the page to load. Entries created by a hash change
+// or history.pushState do not load a new document, so they are complete once the entry is current
+// and the top frame's loader, which only changes for a new document, is the same as before.
+func (t *Tab) navigateToHistoryEntry(entry *gcdapi.PageNavigationEntry) error {
+	if t.IsNavigating() {
+		return &InvalidNavigationErr{Message: "Unable to navigate, already navigating."}
+	}
+	t.setIsNavigating(true)
+	defer t.setIsNavigating(false)
+
+	t.drainNavigationSignals()
+
+	loaderId := t.topLoaderId()
+
+	t.debugf("navigating to history entry %d %s", entry.Id, entry.Url)
+	if _, err := t.Page.NavigateToHistoryEntry(entry.Id); err != nil {
+		return err
+	}
+	t.lastNodeChangeTimeVal.Store(time.Now())
+
+	return t.readyWaitOr(entry.Url, t.navigationTimeout, func() bool {
+		if loaderId == "" || t.topLoaderId() != loaderId {
+			return false
 		}
+		idx, entries, err := t.NavigationHistory()
+		return err == nil && idx >= 0 && idx < len(entries) && entries[idx].Id == entry.Id
+	})
+}
+
+// returns the loader id of the top frame's current document, or empty if it can not be resolved.
+func (t *Tab) topLoaderId() string {
+	resources, err := t.Page.GetResourceTree()
+	if err != nil || resources == nil || resources.Frame == nil {
+		return ""
 	}
-	return nil, &InvalidNavigationErr{Message: "Unable to navigate backward as we are on the first navigation entry"}
+	return resources.Frame.LoaderId
 }
 
 // Calls a function every tick until conditionFn returns true or timeout occurs.
@@ -448,6 +804,74 @@ func (t *Tab) WaitFor(rate, timeout time.Duration, conditionFn ConditionalFunc)
 	}
 }
 
+// Waits for an element matching selector to appear in the top document, such as one added after an
+// ajax call, and returns the first match. The selector is queried again on each check against the
+// current document, so it is safe to call while the page navigates. Returns a TimeoutErr if no element
+// matches before timeout.
+func (t *Tab) WaitForElementBySelector(selector string, timeout time.Duration) (*Element, error) {
+	var found *Element
+	err := t.WaitFor(100*time.Millisecond, timeout, func(tab *Tab) bool {
+		elements, err := tab.GetElementsBySelector(selector)
+		if err != nil || len(elements) == 0 {
+			return false
+		}
+		found = elements[0]
+		return true
+	})
+	if err != nil {
+		return nil, &TimeoutErr{Message: "waiting for element matching " + selector}
+	}
+	return found, nil
+}
+
+// Waits for an element matching selector to both exist in the top document and be visible, see
+// Element.IsVisible, and returns the first visible match. Useful for pages that insert elements
+// before showing them. Returns a TimeoutErr if no matching element is visible before timeout.
+func (t *Tab) WaitForElementVisible(selector string, timeout time.Duration) (*Element, error) {
+	var found *Element
+	err := t.WaitFor(100*time.Millisecond, timeout, func(tab *Tab) bool {
+		elements, err := tab.GetElementsBySelector(selector)
+		if err != nil {
+			return false
+		}
+		for _, ele := range elements {
+			if visible, err := ele.IsVisible(); err == nil && visible {
+				found = ele
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return nil, &TimeoutErr{Message: "waiting for visible element matching " + selector}
+	}
+	return found, nil
+}
+
+// Waits for the first element matching selector in the top document to contain text, such as a status
+// label updated asynchronously. The element is looked up on each check so it may be replaced. Returns a
+// TimeoutErr including the last observed text if it does not contain text before timeout.
+func (t *Tab) WaitForElementText(selector, text string, timeout time.Duration) error {
+	lastText := ""
+	err := t.WaitFor(100*time.Millisecond, timeout, func(tab *Tab) bool {
+		nodeId, err := tab.DOM.QuerySelector(tab.GetTopNodeId(), selector)
+		if err != nil || nodeId == 0 {
+			return false
+		}
+
+		observed, err := tab.nodeText(nodeId)
+		if err != nil {
+			return false
+		}
+		lastText = observed
+		return strings.Contains(lastText, text)
+	})
+	if err != nil {
+		return &TimeoutErr{Message: fmt.Sprintf("waiting for %s to contain %q, last text was %q", selector, text, lastText)}
+	}
+	return nil
+}
+
 // A very rudementary stability check, compare current time with lastNodeChangeTime and see if it
 // is greater than the stableAfter duration. If it is, that means we haven't seen any activity over the minimum
 // allowed time, in which case we consider the DOM stable. Note this will most likely not work for sites
@@ -490,11 +914,196 @@ func (t *Tab) WaitStable() error {
 	return nil
 }
 
+// Waits for DOM mutations to stop for the quietPeriod duration, each node change event restarts the
+// quiet period. Unlike WaitStable, the caller controls the durations and it may be called before any
+// node changes have occurred. Returns a TimeoutErr if the DOM does not settle before timeout.
+func (t *Tab) WaitForStableDOM(quietPeriod, timeout time.Duration) error {
+	start := time.Now()
+	checkRate := quietPeriod / 4
+	if checkRate < time.Millisecond {
+		checkRate = time.Millisecond
+	}
+
+	err := t.WaitFor(checkRate, timeout, func(tab *Tab) bool {
+		lastChange := start
+		if changeTime, ok := tab.lastNodeChangeTimeVal.Load().(time.Time); ok && changeTime.After(start) {
+			lastChange = changeTime
+		}
+		return time.Since(lastChange) >= quietPeriod
+	})
+	if err != nil {
+		return &TimeoutErr{Message: "waiting for DOM mutations to stop"}
+	}
+	return nil
+}
+
+// Waits until the page is ready using the default PageReadyOptions, see WaitForPageReadyWithOptions.
+func (t *Tab) WaitForPageReady(timeout time.Duration) error {
+	return t.WaitForPageReadyWithOptions(nil, timeout)
+}
+
+// Waits until the document's readyState is complete, the network has been idle and the DOM has
+// stopped changing, which is usually when a single page app has finished rendering. This enables
+// the network service if it is not already, requests sent before it was enabled are not tracked.
+// Returns a TimeoutErr describing the conditions which were not met before timeout.
+func (t *Tab) WaitForPageReadyWithOptions(opts *PageReadyOptions, timeout time.Duration) error {
+	opts = opts.withDefaults()
+	if err := t.enableNetwork(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	networkIdleSince := start
+	readyState := ""
+	pending := 0
+	domQuiet := false
+
+	err := t.WaitFor(opts.CheckRate, timeout, func(tab *Tab) bool {
+		now := time.Now()
+		if rro, err := tab.EvaluateScript("document.readyState"); err == nil {
+			if state, ok := rro.Value.(string); ok {
+				readyState = state
+			}
+		}
+
+		pending = len(tab.GetPendingRequests())
+		if pending > opts.MaxInflightRequests {
+			networkIdleSince = now
+		}
+
+		lastChange := start
+		if changeTime, ok := tab.lastNodeChangeTimeVal.Load().(time.Time); ok && changeTime.After(start) {
+			lastChange = changeTime
+		}
+		domQuiet = now.Sub(lastChange) >= opts.DOMQuietPeriod
+
+		return readyState == "complete" && now.Sub(networkIdleSince) >= opts.NetworkIdleTime && domQuiet
+	})
+	if err == nil {
+		return nil
+	}
+
+	failed := make([]string, 0, 3)
+	if readyState != "complete" {
+		failed = append(failed, fmt.Sprintf("document.readyState was %q", readyState))
+	}
+	if time.Since(networkIdleSince) < opts.NetworkIdleTime {
+		failed = append(failed, fmt.Sprintf("network was not idle for %s with %d requests pending", opts.NetworkIdleTime, pending))
+	}
+	if !domQuiet {
+		failed = append(failed, fmt.Sprintf("DOM mutations did not stop for %s", opts.DOMQuietPeriod))
+	}
+	return &TimeoutErr{Message: "waiting for page ready: " + strings.Join(failed, ", ")}
+}
+
 // Returns the source of a script by its scriptId.
 func (t *Tab) GetScriptSource(scriptId string) (string, error) {
 	return t.Debugger.GetScriptSource(scriptId)
 }
 
+// Returns the source of a parsed script whose url contains urlSubstring. This is the source
+// as executed by the page which may differ from what was returned over the network. If more
+// than one script matches, the source of the most recently parsed one is returned.
+func (t *Tab) GetScriptSourceByUrl(urlSubstring string) (string, error) {
+	scriptIds := t.GetScriptIdsByUrl(urlSubstring)
+	if len(scriptIds) == 0 {
+		return "", &ScriptNotFoundErr{Message: "matching url " + urlSubstring}
+	}
+	return t.GetScriptSource(scriptIds[len(scriptIds)-1])
+}
+
+// Replaces the source of a script by its scriptId without reloading the page. Returns
+// an error if chrome is unable to live edit the script.
+func (t *Tab) SetScriptSource(scriptId, scriptSource string) error {
+	params := &gcdapi.DebuggerSetScriptSourceParams{
+		ScriptId:     scriptId,
+		ScriptSource: scriptSource,
+	}
+	_, _, _, exception, err := t.Debugger.SetScriptSourceWithParams(params)
+	if err != nil {
+		return err
+	}
+	if exception != nil {
+		return &ScriptEvaluationErr{Message: "error setting script source: ", ExceptionText: exceptionText(exception), ExceptionDetails: exception}
+	}
+	return nil
+}
+
+// Sets a breakpoint on lineNumber (zero based) of all scripts whose url matches urlRegex, including
+// scripts which have not been parsed yet. Returns the breakpointId which can be used to remove it.
+func (t *Tab) SetBreakpoint(urlRegex string, lineNumber int) (string, error) {
+	params := &gcdapi.DebuggerSetBreakpointByUrlParams{
+		LineNumber: lineNumber,
+		UrlRegex:   urlRegex,
+	}
+	breakpointId, _, err := t.Debugger.SetBreakpointByUrlWithParams(params)
+	return breakpointId, err
+}
+
+// Removes the breakpoint by the breakpointId returned from SetBreakpoint.
+func (t *Tab) RemoveBreakpoint(breakpointId string) error {
+	_, err := t.Debugger.RemoveBreakpoint(breakpointId)
+	return err
+}
+
+// Calls pausedHandlerFn whenever script execution is paused, such as when a breakpoint is hit.
+// The page will stay paused until Resume is called. Call with a nil handler to stop receiving
+// paused events.
+func (t *Tab) OnPaused(pausedHandlerFn DebuggerPausedFunc) {
+	if pausedHandlerFn == nil {
+		t.Unsubscribe("Debugger.paused")
+		return
+	}
+
+	t.Subscribe("Debugger.paused", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.DebuggerPausedEvent{}
+		if err := json.Unmarshal(payload, message); err == nil {
+			pausedHandlerFn(t, message)
+		}
+	})
+}
+
+// Resumes script execution after the debugger has paused.
+func (t *Tab) Resume() error {
+	_, err := t.Debugger.Resume()
+	return err
+}
+
+// Starts sampling a CPU profile of the page's javascript, enabling the Profiler domain. Call
+// StopProfiling after the flow being measured to get the profile.
+func (t *Tab) StartProfiling() error {
+	if _, err := t.Profiler.Enable(); err != nil {
+		return err
+	}
+	_, err := t.Profiler.Start()
+	return err
+}
+
+// Stops the CPU profile started by StartProfiling and disables the Profiler domain. The returned
+// profile can be marshalled to json and saved as a .cpuprofile for loading in DevTools.
+func (t *Tab) StopProfiling() (*gcdapi.ProfilerProfile, error) {
+	profile, err := t.Profiler.Stop()
+	if err != nil {
+		return nil, err
+	}
+	_, err = t.Profiler.Disable()
+	return profile, err
+}
+
+// Returns the scriptIds of all parsed scripts whose url contains urlSubstring, in the order
+// they were parsed.
+func (t *Tab) GetScriptIdsByUrl(urlSubstring string) []string {
+	scriptIds := make([]string, 0)
+	t.scriptMutex.RLock()
+	for _, scriptId := range t.scriptOrder {
+		if strings.Contains(t.scripts[scriptId], urlSubstring) {
+			scriptIds = append(scriptIds, scriptId)
+		}
+	}
+	t.scriptMutex.RUnlock()
+	return scriptIds
+}
+
 // Gets the top document and updates our list of elements DO NOT CALL DOM.GetDocument after
 // the page has loaded, it creates new nodeIds and all functions that look up elements (QuerySelector)
 // will fail.
@@ -521,10 +1130,10 @@ func (t *Tab) GetDocument() (*Element, error) {
 	return docEle, nil
 }
 
-// Returns either an element from our list of ready/known nodeIds or a new un-ready element
-// If it's not ready we return false. Note this does have a side effect of adding a potentially
-// invalid element to our list of known elements. But it is assumed this method will be called
-// with a valid nodeId that chrome has not informed us about yet. Once we are informed, we need
+// Returns either an element from our list of ready/known nodeIds or, for nodeIds chrome has not
+// informed us about yet, a new element populated by describing the node. If the node can not be
+// described we fall back to a new un-ready element and return false. Note this does have a side effect
+// of adding a potentially invalid element to our list of known elements. Once we are informed, we need
 // to update it via our list and not some reference that could disappear.
 func (t *Tab) GetElementByNodeId(nodeId int) (*Element, bool) {
 	t.eleMutex.RLock()
@@ -533,11 +1142,26 @@ func (t *Tab) GetElementByNodeId(nodeId int) (*Element, bool) {
 	if ok {
 		return ele, true
 	}
-	newEle := newElement(t, nodeId)
+
+	node, err := t.describeNode(nodeId)
+
 	t.eleMutex.Lock()
-	t.elements[nodeId] = newEle // add non-ready element to our list.
-	t.eleMutex.Unlock()
-	return newEle, false
+	defer t.eleMutex.Unlock()
+	// we may have been informed of the node while describing it.
+	if ele, ok := t.elements[nodeId]; ok {
+		return ele, true
+	}
+
+	if err != nil {
+		t.debugf("unable to describe node %d: %s\n", nodeId, err)
+		newEle := newElement(t, nodeId)
+		t.elements[nodeId] = newEle // add non-ready element to our list.
+		return newEle, false
+	}
+
+	newEle := newReadyElement(t, node)
+	t.elements[nodeId] = newEle
+	return newEle, true
 }
 
 // Returns the element given the x, y coordinates on the page, or returns error.
@@ -587,6 +1211,82 @@ func (t *Tab) GetDocumentElementById(docNodeId int, attributeId string) (*Elemen
 	return ele, ready, nil
 }
 
+// Returns the first element in the top level document matching selector, or ElementNotFoundErr
+// if nothing matches. See GetElementsBySelector for all matches.
+func (t *Tab) GetElementBySelector(selector string) (*Element, error) {
+	return t.GetDocumentElementBySelector(t.GetTopNodeId(), selector)
+}
+
+// Returns the first element in a specific Document matching selector, or ElementNotFoundErr if
+// nothing matches.
+func (t *Tab) GetDocumentElementBySelector(docNodeId int, selector string) (*Element, error) {
+	docNode, ok := t.getElement(docNodeId)
+	if !ok {
+		return nil, &ElementNotFoundErr{Message: fmt.Sprintf("docNodeId %d not found", docNodeId)}
+	}
+
+	nodeId, err := t.DOM.QuerySelector(docNode.id, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	if nodeId == 0 {
+		return nil, &ElementNotFoundErr{Message: "matching " + selector}
+	}
+
+	ele, _ := t.GetElementByNodeId(nodeId)
+	return ele, nil
+}
+
+// Returns the first element matching selector in the document of frameId, such as an iframe's content,
+// or ElementNotFoundErr if nothing matches. The frame must be accessible, see GetAccessibleFrames,
+// cross origin frames loaded in another process return an error.
+func (t *Tab) GetElementBySelectorInFrame(frameId, selector string) (*Element, error) {
+	if frameId == t.GetTopFrameId() {
+		return t.GetElementBySelector(selector)
+	}
+
+	owner, err := t.GetFrameOwnerElement(frameId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := owner.WaitForReady(); err != nil {
+		return nil, err
+	}
+
+	docNodeId, err := owner.GetFrameDocumentNodeId()
+	if err != nil {
+		return nil, &InvalidFrameErr{Message: "unable to get document of frameId " + frameId + ": " + err.Error()}
+	}
+	return t.GetDocumentElementBySelector(docNodeId, selector)
+}
+
+// Returns a ready element by searching the top level document for an element with attributeId.
+// Unlike GetElementById, the node is described directly by chrome so the caller does not need to
+// wait for the element to be populated by DOM events. Returns ElementNotFoundErr if no element matches.
+func (t *Tab) GetElementByIdFast(attributeId string) (*Element, error) {
+	nodeId, err := t.DOM.QuerySelector(t.GetTopNodeId(), "#"+attributeId)
+	if err != nil {
+		return nil, err
+	}
+
+	if nodeId == 0 {
+		return nil, &ElementNotFoundErr{Message: "element with id " + attributeId + " not found"}
+	}
+
+	if ele, ok := t.getElement(nodeId); ok && ele.IsReady() {
+		return ele, nil
+	}
+
+	node, err := t.describeNode(nodeId)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.nodeToElement(node), nil
+}
+
 // Get all elements that match a selector from the top level document
 func (t *Tab) GetElementsBySelector(selector string) ([]*Element, error) {
 	return t.GetDocumentElementsBySelector(t.GetTopNodeId(), selector)
@@ -653,6 +1353,32 @@ func (t *Tab) GetDocumentElementsBySelector(docNodeId int, selector string) ([]*
 	return elements, nil
 }
 
+// Returns all elements matching the xpath expression, such as //a[contains(text(), 'Next')], in the
+// loaded documents of the tab. The search is discarded once the matching nodes are returned.
+func (t *Tab) GetElementsByXPath(xpath string) ([]*Element, error) {
+	searchId, count, err := t.DOM.PerformSearch(xpath, false)
+	if err != nil {
+		return nil, err
+	}
+	defer t.DOM.DiscardSearchResults(searchId)
+
+	elements := make([]*Element, 0, count)
+	if count == 0 {
+		return elements, nil
+	}
+
+	nodeIds, err := t.DOM.GetSearchResults(searchId, 0, count)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, nodeId := range nodeIds {
+		element, _ := t.GetElementByNodeId(nodeId)
+		elements = append(elements, element)
+	}
+	return elements, nil
+}
+
 // Returns the document's source, as visible, if docId is 0, returns top document source.
 func (t *Tab) GetPageSource(docNodeId int) (string, error) {
 	if docNodeId == 0 {
@@ -671,6 +1397,21 @@ func (t *Tab) GetCurrentUrl() (string, error) {
 	return t.GetDocumentCurrentUrl(t.GetTopNodeId())
 }
 
+// Returns the url of the top level document as the page sees it, window.location.href. Unlike GetCurrentUrl,
+// which is the url the document was loaded from, this includes history.pushState and hash changes.
+func (t *Tab) GetURL() (string, error) {
+	resp, err := t.EvaluateScript("window.top.location.href")
+	if err != nil {
+		return "", err
+	}
+
+	url, ok := resp.Value.(string)
+	if !ok {
+		return "", &ScriptEvaluationErr{Message: "url was not a string", ExceptionText: "unable to retrieve location, was not a string"}
+	}
+	return url, nil
+}
+
 // Returns the current url of the provided docNodeId
 func (t *Tab) GetDocumentCurrentUrl(docNodeId int) (string, error) {
 	docNode, ok := t.getElement(docNodeId)
@@ -682,18 +1423,28 @@ func (t *Tab) GetDocumentCurrentUrl(docNodeId int) (string, error) {
 
 // Issues a left button mousePressed then mouseReleased on the x, y coords provided.
 func (t *Tab) Click(x, y float64) error {
-	return t.click(x, y, 1)
+	return t.ClickAt(x, y, ClickOptions{})
 }
 
-func (t *Tab) click(x, y float64, clickCount int) error {
+// Issues a mousePressed then mouseReleased on the x, y coords provided using the button, click count
+// and modifier keys of opts, for example a ctrl+click or middle click.
+func (t *Tab) ClickAt(x, y float64, opts ClickOptions) error {
 	// "mousePressed", "mouseReleased", "mouseMoved"
 	// enum": ["none", "left", "middle", "right"]
+	if opts.Button == "" {
+		opts.Button = "left"
+	}
+
+	if opts.ClickCount == 0 {
+		opts.ClickCount = 1
+	}
 
 	mousePressedParams := &gcdapi.InputDispatchMouseEventParams{TheType: "mousePressed",
 		X:          x,
 		Y:          y,
-		Button:     "left",
-		ClickCount: clickCount,
+		Modifiers:  opts.modifiers(),
+		Button:     opts.Button,
+		ClickCount: opts.ClickCount,
 	}
 
 	if _, err := t.Input.DispatchMouseEventWithParams(mousePressedParams); err != nil {
@@ -703,309 +1454,1799 @@ func (t *Tab) click(x, y float64, clickCount int) error {
 	mouseReleasedParams := &gcdapi.InputDispatchMouseEventParams{TheType: "mouseReleased",
 		X:          x,
 		Y:          y,
-		Button:     "left",
-		ClickCount: clickCount,
+		Modifiers:  opts.modifiers(),
+		Button:     opts.Button,
+		ClickCount: opts.ClickCount,
+	}
+
+	if _, err := t.Input.DispatchMouseEventWithParams(mouseReleasedParams); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Issues a double click on the x, y coords provided. Like a real double click, a click with a click count
+// of 1 is followed by a click with a click count of 2, so the page receives two click events then a dblclick.
+func (t *Tab) DoubleClick(x, y float64) error {
+	if err := t.ClickAt(x, y, ClickOptions{ClickCount: 1}); err != nil {
+		return err
+	}
+	return t.ClickAt(x, y, ClickOptions{ClickCount: 2})
+}
+
+// Taps the x, y coords provided by dispatching a touchStart then touchEnd, call SetTouchEmulation
+// first so pages treat the tab as a touch device.
+func (t *Tab) Tap(x, y float64) error {
+	touchStartParams := &gcdapi.InputDispatchTouchEventParams{TheType: "touchStart",
+		TouchPoints: []*gcdapi.InputTouchPoint{{X: x, Y: y}},
+	}
+
+	if _, err := t.Input.DispatchTouchEventWithParams(touchStartParams); err != nil {
+		return err
+	}
+
+	touchEndParams := &gcdapi.InputDispatchTouchEventParams{TheType: "touchEnd",
+		TouchPoints: []*gcdapi.InputTouchPoint{},
+	}
+
+	_, err := t.Input.DispatchTouchEventWithParams(touchEndParams)
+	return err
+}
+
+// Moves the mouse to the x, y coords provided.
+func (t *Tab) MoveMouse(x, y float64) error {
+	mouseMovedParams := &gcdapi.InputDispatchMouseEventParams{TheType: "mouseMoved",
+		X: x,
+		Y: y,
+	}
+
+	_, err := t.Input.DispatchMouseEventWithParams(mouseMovedParams)
+	return err
+}
+
+// Scrolls the mouse wheel at the x, y coords provided by deltaX and deltaY css pixels, scrolling
+// whichever scroll container is under the coords. Positive deltaY scrolls down. Scrolling happens
+// asynchronously after this returns.
+func (t *Tab) ScrollWheel(x, y, deltaX, deltaY float64) error {
+	return overridenDispatchMouseWheelEvent(t.ChromeTarget, x, y, deltaX, deltaY)
+}
+
+// Clicks every element in the top document matching selector in document order, such as to expand all
+// sections. Each element is scrolled into view and its position looked up again before it is clicked as
+// earlier clicks may move it. Elements which were removed or are still not visible are skipped and the
+// remaining elements are still clicked, if any fail or are skipped an AggregateErr is returned holding
+// each element's error. Returns an ElementNotFoundErr if nothing matches.
+func (t *Tab) ClickAll(selector string) error {
+	elements, err := t.GetElementsBySelector(selector)
+	if err != nil {
+		return err
+	}
+
+	if len(elements) == 0 {
+		return &ElementNotFoundErr{Message: "matching " + selector}
+	}
+
+	errs := make([]error, 0)
+	for i, element := range elements {
+		// hidden elements can't be scrolled to, so check visibility before reporting a scroll error
+		scrollErr := element.ScrollIntoView()
+		visible, err := element.IsVisible()
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("%s[%d]: %s", selector, i, err))
+		case !visible:
+			errs = append(errs, fmt.Errorf("%s[%d]: skipped, element is not visible", selector, i))
+		case scrollErr != nil:
+			errs = append(errs, fmt.Errorf("%s[%d]: %s", selector, i, scrollErr))
+		default:
+			if err := element.Click(); err != nil {
+				errs = append(errs, fmt.Errorf("%s[%d]: %s", selector, i, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return &AggregateErr{Message: "failed to click all elements", Errors: errs}
+	}
+	return nil
+}
+
+// Fills in form fields of the top document in the order given, for each field the first element
+// matching its selector is focused, cleared and then has the value typed into it. All fields are
+// attempted, if any fail an AggregateErr is returned holding each field's error. If submitSelector
+// is not empty and every field was filled, the first element matching it is submitted afterwards:
+// a form is submitted as if by its submit button, any other element, such as a button, is clicked.
+func (t *Tab) FillForm(fields []FormField, submitSelector string) error {
+	errs := make([]error, 0)
+	for _, field := range fields {
+		if err := t.fillField(field.Selector, field.Value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", field.Selector, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return &AggregateErr{Message: "failed to fill form", Errors: errs}
+	}
+
+	if submitSelector == "" {
+		return nil
+	}
+	return t.submitForm(submitSelector)
+}
+
+// Focuses, clears and types value into the first element matching selector.
+func (t *Tab) fillField(selector, value string) error {
+	nodeId, err := t.DOM.QuerySelector(t.GetTopNodeId(), selector)
+	if err != nil {
+		return err
+	}
+
+	if nodeId == 0 {
+		return &ElementNotFoundErr{Message: "no element matches " + selector}
+	}
+
+	ele, _ := t.GetElementByNodeId(nodeId)
+	if err := ele.WaitForReady(); err != nil {
+		return err
+	}
+
+	if err := ele.Focus(); err != nil {
+		return err
+	}
+
+	if err := ele.Clear(); err != nil {
+		return err
+	}
+	return ele.SendKeys(value)
+}
+
+// Submits the form matching selector, or clicks the element matching selector if it is not a form.
+func (t *Tab) submitForm(selector string) error {
+	ele, err := t.GetElementBySelector(selector)
+	if err != nil {
+		return err
+	}
+
+	if err := ele.WaitForReady(); err != nil {
+		return err
+	}
+
+	if tagName, _ := ele.GetTagName(); tagName != "form" {
+		return ele.Click()
+	}
+
+	submit := "function() { if (this.requestSubmit) { this.requestSubmit(); } else { this.submit(); } }"
+	_, err = t.callFunctionOnNode(ele.NodeId(), submit)
+	return err
+}
+
+// Sends keystrokes to whatever is focused, best called from Element.SendKeys which will
+// try to focus on the element first. Use \n for Enter, \b for backspace or \t for Tab.
+// Characters which need shift on a US keyboard, such as uppercase letters, are sent with
+// the shift modifier.
+func (t *Tab) SendKeys(text string) error {
+	for _, inputchar := range text {
+		if err := t.pressKey(inputchar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Dispatches the rawKeyDown, char and keyUp events of typing a single character.
+func (t *Tab) pressKey(char rune) error {
+	inputParams := keyEventParams(char)
+
+	inputParams.TheType = "rawKeyDown"
+	if _, err := t.Input.DispatchKeyEventWithParams(inputParams); err != nil {
+		return err
+	}
+
+	inputParams.TheType = "char"
+	if _, err := t.Input.DispatchKeyEventWithParams(inputParams); err != nil {
+		return err
+	}
+
+	inputParams.TheType = "keyUp"
+	if _, err := t.Input.DispatchKeyEventWithParams(inputParams); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Injects custom javascript prior to the page loading on all frames. Returns scriptId which
+// can be used to remove the script. If you only want the script to interact with the top
+// document, you'll need to do checks in the injected script such as testing location.href.
+//
+// Alternatively, you can use Tab.EvaluateScript to only work on the global context.
+func (t *Tab) InjectScriptOnLoad(scriptSource string) (string, error) {
+	scriptId, err := t.Page.AddScriptToEvaluateOnLoad(scriptSource)
+	if err != nil {
+		return "", err
+	}
+	return scriptId, nil
+}
+
+// Removes the script by the scriptId.
+func (t *Tab) RemoveScriptFromOnLoad(scriptId string) error {
+	_, err := t.Page.RemoveScriptToEvaluateOnLoad(scriptId)
+	return err
+}
+
+// Same as EvaluateScript but gives up after timeout, returning a TimeoutErr. The script is aborted with
+// Runtime.terminateExecution so an infinite loop in it does not leave the page unresponsive.
+func (t *Tab) EvaluateScriptWithTimeout(scriptSource string, timeout time.Duration) (*gcdapi.RuntimeRemoteObject, error) {
+	type evaluateResult struct {
+		rro *gcdapi.RuntimeRemoteObject
+		err error
+	}
+	resultCh := make(chan *evaluateResult, 1)
+
+	go func() {
+		rro, err := t.EvaluateScript(scriptSource)
+		resultCh <- &evaluateResult{rro: rro, err: err}
+	}()
+
+	timeoutTimer := time.NewTimer(timeout)
+	defer timeoutTimer.Stop()
+
+	select {
+	case result := <-resultCh:
+		return result.rro, result.err
+	case <-timeoutTimer.C:
+		if err := overridenTerminateExecution(t.ChromeTarget); err != nil {
+			t.debugf("unable to terminate script execution: %s\n", err)
+		}
+		return nil, &TimeoutErr{Message: "evaluating script"}
+	}
+}
+
+// Evaluates script in the global context. Evaluations are treated as initiated by the user, so
+// APIs such as the clipboard, fullscreen or autoplay which require a user gesture are allowed.
+func (t *Tab) EvaluateScript(scriptSource string) (*gcdapi.RuntimeRemoteObject, error) {
+	return t.evaluateScript(scriptSource, 0, false)
+}
+
+// Evaluates script in the global context.
+func (t *Tab) EvaluatePromiseScript(scriptSource string) (*gcdapi.RuntimeRemoteObject, error) {
+	return t.evaluateScript(scriptSource, 0, true)
+}
+
+// Evaluates expression in the global context as if initiated by the user, for APIs such as the clipboard,
+// fullscreen or autoplay that require a user gesture. Exceptions are returned as a ScriptEvaluationErr.
+// The same as EvaluateScript, which always sends the user gesture flag, but states the intent at the call site.
+func (t *Tab) EvaluateWithUserGesture(expression string) (*gcdapi.RuntimeRemoteObject, error) {
+	return t.evaluateScript(expression, 0, false)
+}
+
+// Returns the text content of the clipboard, after granting the current page's origin permission to
+// read it. Requires a chrome version supporting Browser.grantPermissions and navigator.clipboard.
+func (t *Tab) ReadClipboard() (string, error) {
+	rro, err := t.EvaluateScript("location.origin")
+	if err != nil {
+		return "", err
+	}
+
+	origin, ok := rro.Value.(string)
+	if !ok {
+		return "", &ScriptEvaluationErr{Message: "origin was not a string", ExceptionText: "unable to retrieve the page origin"}
+	}
+
+	if err := overridenGrantPermissions(t.ChromeTarget, origin, []string{"clipboardRead"}); err != nil {
+		return "", err
+	}
+
+	rro, err = t.evaluateScript("navigator.clipboard.readText()", 0, true)
+	if err != nil {
+		if scriptErr, ok := err.(*ScriptEvaluationErr); ok {
+			scriptErr.Message = "unable to read clipboard, permission may have been denied: "
+		}
+		return "", err
+	}
+
+	text, ok := rro.Value.(string)
+	if !ok {
+		return "", &ScriptEvaluationErr{Message: "clipboard text was not a string", ExceptionText: "unable to read clipboard"}
+	}
+	return text, nil
+}
+
+// Evaluates script in the default execution context of the frame. Returns an error if we have
+// not been notified of an execution context for the frame.
+func (t *Tab) EvaluateScriptOnFrame(frameId, scriptSource string) (*gcdapi.RuntimeRemoteObject, error) {
+	contextId, err := t.GetExecutionContextForFrame(frameId)
+	if err != nil {
+		return nil, err
+	}
+	return t.evaluateScript(scriptSource, contextId, false)
+}
+
+// Returns the default execution context id of the frame, or an error if we have not been
+// notified of one or it has since been destroyed.
+func (t *Tab) GetExecutionContextForFrame(frameId string) (int, error) {
+	t.contextMutex.RLock()
+	defer t.contextMutex.RUnlock()
+
+	contextId, ok := t.frameContexts[frameId]
+	if !ok {
+		return 0, &InvalidFrameErr{Message: "no execution context for frameId " + frameId}
+	}
+	return contextId, nil
+}
+
+// Posts message to the frame's contentWindow from the top frame, as if the top document had called
+// frame.contentWindow.postMessage(message, targetOrigin). Returns an error if the frame has no
+// execution context or its owner element can not be resolved.
+func (t *Tab) PostMessageToFrame(frameId, message, targetOrigin string) error {
+	if _, err := t.GetExecutionContextForFrame(frameId); err != nil {
+		return err
+	}
+
+	ownerNodeId, err := t.frameOwnerNodeId(frameId)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.callFunctionOnNode(ownerNodeId, "function(message, targetOrigin) { this.contentWindow.postMessage(message, targetOrigin); }", message, targetOrigin)
+	return err
+}
+
+// Replaces the document of frameId with html by calling document.open, write and close on the
+// frame's contentDocument from the owner element, so the parent page observes the change as if its
+// own script had written the frame. Returns an InvalidFrameErr if the frame has no execution context
+// or its document is not accessible, such as a cross origin frame.
+func (t *Tab) SetFrameContent(frameId, html string) error {
+	if _, err := t.GetExecutionContextForFrame(frameId); err != nil {
+		return err
+	}
+
+	ownerNodeId, err := t.frameOwnerNodeId(frameId)
+	if err != nil {
+		return err
+	}
+
+	setContent := `function(html) {
+	var doc;
+	try { doc = this.contentDocument; } catch (e) { return false; }
+	if (!doc) { return false; }
+	doc.open();
+	doc.write(html);
+	doc.close();
+	return true;
+}`
+	rro, err := t.callFunctionOnNode(ownerNodeId, setContent, html)
+	if err != nil {
+		return err
+	}
+
+	if written, ok := rro.Value.(bool); !ok || !written {
+		return &InvalidFrameErr{Message: "document of frameId " + frameId + " is not accessible"}
+	}
+	return nil
+}
+
+// Listens for messages posted to the top window, records them and returns the first message
+// data for which predicate returns true. Non string data is JSON serialized. The listener is added
+// to the current document and injected into any subsequently loaded documents. Messages which do not
+// match are discarded. Returns a TimeoutErr if no matching message is received before timeout.
+func (t *Tab) WaitForPostMessage(predicate func(data string) bool, timeout time.Duration) (string, error) {
+	if err := t.listenPostMessages(); err != nil {
+		return "", err
+	}
+
+	var data string
+	err := t.WaitFor(100*time.Millisecond, timeout, func(tab *Tab) bool {
+		rro, err := tab.EvaluateScript("(function() { var m = window.__autogcdPostMessages || []; window.__autogcdPostMessages = []; return m; })()")
+		if err != nil {
+			return false
+		}
+		messages, ok := rro.Value.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, message := range messages {
+			if messageData, ok := message.(string); ok && predicate(messageData) {
+				data = messageData
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return "", &TimeoutErr{Message: "waiting for post message"}
+	}
+	return data, nil
+}
+
+// Adds our post message listener to the current document and injects it on load, only once.
+func (t *Tab) listenPostMessages() error {
+	listener := `(function() {
+	if (window !== window.top || window.__autogcdPostMessages) { return; }
+	window.__autogcdPostMessages = [];
+	window.addEventListener('message', function(e) {
+		window.__autogcdPostMessages.push(typeof e.data === 'string' ? e.data : JSON.stringify(e.data));
+	});
+})();`
+
+	t.postMessageMutex.Lock()
+	defer t.postMessageMutex.Unlock()
+
+	if t.postMessageScriptId != "" {
+		return nil
+	}
+
+	scriptId, err := t.InjectScriptOnLoad(listener)
+	if err != nil {
+		return err
+	}
+
+	if _, err := t.EvaluateScript(listener); err != nil {
+		t.RemoveScriptFromOnLoad(scriptId)
+		return err
+	}
+	t.postMessageScriptId = scriptId
+	return nil
+}
+
+// Returns the iframe/frame element which hosts frameId. Returns an InvalidFrameErr for the top
+// frame, which has no owner.
+func (t *Tab) GetFrameOwnerElement(frameId string) (*Element, error) {
+	if frameId == t.GetTopFrameId() {
+		return nil, &InvalidFrameErr{Message: "the top frame has no owner element"}
+	}
+
+	nodeId, err := t.frameOwnerNodeId(frameId)
+	if err != nil {
+		return nil, err
+	}
+
+	ele, _ := t.GetElementByNodeId(nodeId)
+	return ele, nil
+}
+
+// Returns the nodeId of the iframe/frame element which owns frameId. The top frame has no owner.
+func (t *Tab) frameOwnerNodeId(frameId string) (int, error) {
+	nodeId, err := t.DOM.GetFrameOwner(frameId)
+	if err != nil {
+		return 0, &InvalidFrameErr{Message: "unable to get owner of frameId " + frameId + ": " + err.Error()}
+	}
+	return nodeId, nil
+}
+
+// Returns the rendered text of the node (innerText), or its textContent for nodes which do not
+// have innerText such as svg elements.
+func (t *Tab) nodeText(nodeId int) (string, error) {
+	rro, err := t.callFunctionOnNode(nodeId, "function() { return typeof this.innerText === 'string' ? this.innerText : (this.textContent || ''); }")
+	if err != nil {
+		return "", err
+	}
+
+	text, ok := rro.Value.(string)
+	if !ok {
+		return "", &ScriptEvaluationErr{Message: "text was not a string", ExceptionText: "unable to retrieve node text"}
+	}
+	return text, nil
+}
+
+// Calls functionDeclaration with 'this' bound to the resolved node, passing args by value and
+// returning the result by value.
+func (t *Tab) callFunctionOnNode(nodeId int, functionDeclaration string, args ...interface{}) (*gcdapi.RuntimeRemoteObject, error) {
+	node, err := t.DOM.ResolveNodeWithParams(&gcdapi.DOMResolveNodeParams{NodeId: nodeId, ObjectGroup: "autogcd"})
+	if err != nil {
+		return nil, &ElementNotFoundErr{Message: fmt.Sprintf("unable to resolve nodeId %d: %s", nodeId, err)}
+	}
+	defer t.Runtime.ReleaseObject(node.ObjectId)
+
+	callArgs := make([]*gcdapi.RuntimeCallArgument, len(args))
+	for i, arg := range args {
+		callArgs[i] = &gcdapi.RuntimeCallArgument{Value: arg}
+	}
+
+	params := &gcdapi.RuntimeCallFunctionOnParams{
+		FunctionDeclaration: functionDeclaration,
+		ObjectId:            node.ObjectId,
+		Arguments:           callArgs,
+		Silent:              true,
+		ReturnByValue:       true,
+		UserGesture:         true,
+	}
+
+	rro, exception, err := t.Runtime.CallFunctionOnWithParams(params)
+	if err != nil {
+		return nil, err
+	}
+	if exception != nil {
+		return nil, &ScriptEvaluationErr{Message: "error calling function on node: ", ExceptionText: exceptionText(exception), ExceptionDetails: exception}
+	}
+	return rro, nil
+}
+
+// Evaluates script in the execution context of contextId, or the global context if contextId is 0.
+func (t *Tab) evaluateScript(scriptSource string, contextId int, awaitPromise bool) (*gcdapi.RuntimeRemoteObject, error) {
+	objectGroup := "autogcd"
+	includeCommandLineAPI := true
+	silent := true
+	returnByValue := true
+	generatePreview := true
+	userGestures := true
+	rro, exception, err := overridenRuntimeEvaluate(t.ChromeTarget, scriptSource, objectGroup, includeCommandLineAPI, silent, contextId, returnByValue, generatePreview, userGestures, awaitPromise)
+	if err != nil {
+		return nil, err
+	}
+	if exception != nil {
+		return nil, &ScriptEvaluationErr{Message: "error executing script: ", ExceptionText: exceptionText(exception), ExceptionDetails: exception}
+	}
+	return rro, nil
+}
+
+// Returns the text of a thrown exception including its message, exception.Text alone is only "Uncaught".
+func exceptionText(exception *gcdapi.RuntimeExceptionDetails) string {
+	if exception.Exception != nil && exception.Exception.Description != "" {
+		return exception.Text + ": " + exception.Exception.Description
+	}
+	return exception.Text
+}
+
+// Adds a <style> element containing css to the current document, returning its id for use with
+// RemoveInjectedStyle. For example "*{animation:none!important;transition:none!important}" stops
+// animations for stable screenshots. The style does not persist across navigations.
+func (t *Tab) InjectStyle(css string) (string, error) {
+	cssJSON, err := json.Marshal(css)
+	if err != nil {
+		return "", err
+	}
+
+	script := fmt.Sprintf(`(function(css) {
+	var style = document.createElement('style');
+	style.id = 'autogcd-style-' + Math.random().toString(36).slice(2);
+	style.textContent = css;
+	(document.head || document.documentElement).appendChild(style);
+	return style.id;
+})(%s)`, cssJSON)
+
+	rro, err := t.EvaluateScript(script)
+	if err != nil {
+		return "", err
+	}
+
+	styleId, ok := rro.Value.(string)
+	if !ok {
+		return "", &ScriptEvaluationErr{Message: "style id was not a string", ExceptionText: "unable to inject style"}
+	}
+	return styleId, nil
+}
+
+// Removes a <style> element added by InjectStyle, returns ElementNotFoundErr if it no longer exists.
+func (t *Tab) RemoveInjectedStyle(styleId string) error {
+	styleIdJSON, err := json.Marshal(styleId)
+	if err != nil {
+		return err
+	}
+
+	script := fmt.Sprintf(`(function(id) {
+	var style = document.getElementById(id);
+	if (!style) { return false; }
+	style.parentNode.removeChild(style);
+	return true;
+})(%s)`, styleIdJSON)
+
+	rro, err := t.EvaluateScript(script)
+	if err != nil {
+		return err
+	}
+
+	if removed, ok := rro.Value.(bool); !ok || !removed {
+		return &ElementNotFoundErr{Message: "injected style " + styleId + " not found"}
+	}
+	return nil
+}
+
+// Loads jQuery from the url set with SetJQueryUrl into the top document so later scripts can use it,
+// unless the page already has a window.jQuery which is left as is to avoid conflicts. Returns an error
+// if the script fails to load, for example because the page's content security policy blocks it.
+func (t *Tab) InjectjQuery() error {
+	urlJSON, err := json.Marshal(t.jQueryUrl)
+	if err != nil {
+		return err
+	}
+
+	script := fmt.Sprintf(`(function(url) {
+	return new Promise(function(resolve, reject) {
+		if (window.jQuery) { resolve(true); return; }
+		var script = document.createElement('script');
+		script.src = url;
+		script.onload = function() { resolve(!!window.jQuery); };
+		script.onerror = function() { reject(new Error('unable to load ' + url)); };
+		(document.head || document.documentElement).appendChild(script);
+	});
+})(%s)`, urlJSON)
+
+	rro, err := t.EvaluatePromiseScript(script)
+	if err != nil {
+		return err
+	}
+
+	if loaded, ok := rro.Value.(bool); !ok || !loaded {
+		return &ScriptEvaluationErr{Message: "error injecting jQuery: ", ExceptionText: "window.jQuery is not defined after loading " + t.jQueryUrl}
+	}
+	return nil
+}
+
+// Takes a screenshot of the currently loaded page (only the dimensions visible in browser window)
+func (t *Tab) GetScreenShot() ([]byte, error) {
+	return t.captureScreenShot(nil)
+}
+
+// Takes a screenshot of the whole page rather than just the viewport, by temporarily resizing the
+// viewport to the size of the page's content. Any device being emulated is restored afterwards, the
+// device scale factor and mobile flag are kept while capturing.
+func (t *Tab) GetFullPageScreenShot() ([]byte, error) {
+	t.emulationMutex.Lock()
+	defer t.emulationMutex.Unlock()
+
+	_, _, contentSize, err := t.Page.GetLayoutMetrics()
+	if err != nil {
+		return nil, err
+	}
+
+	width := int(math.Ceil(contentSize.Width))
+	height := int(math.Ceil(contentSize.Height))
+	if width == 0 || height == 0 {
+		return nil, &InvalidDimensionsErr{Message: "page has no content"}
+	}
+
+	metrics := &gcdapi.EmulationSetDeviceMetricsOverrideParams{Width: width, Height: height}
+	if t.emulatedDevice != nil {
+		metrics.DeviceScaleFactor = t.emulatedDevice.DeviceScaleFactor
+		metrics.Mobile = t.emulatedDevice.Mobile
+	}
+
+	if _, err := t.Emulation.SetDeviceMetricsOverrideWithParams(metrics); err != nil {
+		return nil, err
+	}
+
+	img, err := t.captureScreenShot(&gcdapi.PageViewport{Width: float64(width), Height: float64(height), Scale: 1})
+
+	// restore the viewport even if capturing failed
+	var restoreErr error
+	if t.emulatedDevice != nil {
+		_, restoreErr = t.Emulation.SetDeviceMetricsOverrideWithParams(t.emulatedDevice.metricsParams())
+	} else {
+		_, restoreErr = t.Emulation.ClearDeviceMetricsOverride()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	if restoreErr != nil {
+		return nil, restoreErr
+	}
+	return img, nil
+}
+
+// Takes count screenshots of the viewport, one every interval starting immediately, to capture animations
+// or loading sequences. Each frame is a full png held in memory, so keep count low for large viewports.
+// Capturing takes time, frames will be further apart than interval if it is shorter than a capture. An
+// interval of 0 or less captures frames back to back, a count of 0 or less returns no frames.
+func (t *Tab) CaptureFrames(count int, interval time.Duration) ([][]byte, error) {
+	if count <= 0 {
+		return [][]byte{}, nil
+	}
+	frames := make([][]byte, 0, count)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		frame, err := t.GetScreenShot()
+		if err != nil {
+			return nil, err
+		}
+
+		frames = append(frames, frame)
+		if len(frames) == count {
+			return frames, nil
+		}
+
+		if tick != nil {
+			<-tick
+		}
+	}
+}
+
+// Prints the page to a PDF with opts, returning the PDF bytes. Only supported by headless chrome.
+func (t *Tab) PrintToPDF(opts PDFOptions) ([]byte, error) {
+	pdf, err := overridenPrintToPDF(t.ChromeTarget, opts.params())
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(pdf)
+}
+
+// Takes a screenshot of each element in the top document matching selector, keyed by the element's
+// nodeId. Each element is scrolled into view and the screenshot clipped to its border box. All elements
+// are attempted, if any fail the successful screenshots are returned with an AggregateErr.
+func (t *Tab) ScreenshotElements(selector string) (map[int][]byte, error) {
+	elements, err := t.GetElementsBySelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	screenShots := make(map[int][]byte, len(elements))
+	errs := make([]error, 0)
+	for _, ele := range elements {
+		imgBytes, err := ele.GetScreenShot()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("nodeId %d: %s", ele.NodeId(), err))
+			continue
+		}
+		screenShots[ele.NodeId()] = imgBytes
+	}
+
+	if len(errs) > 0 {
+		return screenShots, &AggregateErr{Message: "failed to screenshot elements", Errors: errs}
+	}
+	return screenShots, nil
+}
+
+// Captures a png screenshot, clipped to the page coordinates of clip if it is not nil.
+func (t *Tab) captureScreenShot(clip *gcdapi.PageViewport) ([]byte, error) {
+	params := &gcdapi.PageCaptureScreenshotParams{
+		Format:  "png",
+		Quality: 100,
+		Clip:    clip,
+	}
+
+	img, err := t.Page.CaptureScreenshotWithParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(img)
+}
+
+// Returns the top document title
+func (t *Tab) GetTitle() (string, error) {
+	var title string
+	var ok bool
+
+	resp, err := t.EvaluateScript("window.top.document.title")
+	if err != nil {
+		return "", err
+	}
+
+	if title, ok = resp.Value.(string); !ok {
+		return "", &ScriptEvaluationErr{Message: "title was not a string", ExceptionText: "unable to retrieve document title, was not a string"}
+	}
+	return title, nil
+}
+
+// Returns the human visible text of the top document (document.body.innerText), as opposed to
+// GetPageSource which returns the serialized HTML. Returns an error if there is no body yet.
+func (t *Tab) GetVisibleText() (string, error) {
+	resp, err := t.EvaluateScript("document.body ? document.body.innerText : null")
+	if err != nil {
+		return "", err
+	}
+
+	text, ok := resp.Value.(string)
+	if !ok {
+		return "", &ElementNotFoundErr{Message: "document body is not available"}
+	}
+	return text, nil
+}
+
+// Returns the content of the top document's meta tags keyed by their name or property attribute, which
+// covers Open Graph (og:title) and Twitter card (twitter:card) tags. Tags with neither are skipped. The
+// map is empty if there are no meta tags.
+func (t *Tab) GetMetaTags() (map[string]string, error) {
+	nodeIds, err := t.DOM.QuerySelectorAll(t.GetTopNodeId(), "meta")
+	if err != nil {
+		return nil, err
+	}
+
+	metaTags := make(map[string]string, len(nodeIds))
+	for _, nodeId := range nodeIds {
+		attr, err := t.DOM.GetAttributes(nodeId)
+		if err != nil {
+			return nil, err
+		}
+
+		attributes := make(map[string]string, len(attr)/2)
+		for i := 0; i+1 < len(attr); i += 2 {
+			attributes[strings.ToLower(attr[i])] = attr[i+1]
+		}
+
+		if name, ok := attributes["name"]; ok && name != "" {
+			metaTags[name] = attributes["content"]
+		}
+		if property, ok := attributes["property"]; ok && property != "" {
+			metaTags[property] = attributes["content"]
+		}
+	}
+	return metaTags, nil
+}
+
+// Returns the unique hrefs of the top document's links resolved to absolute urls, in document order.
+// If sameOrigin is true only links with the same scheme, host and port as the page are returned.
+func (t *Tab) GetLinks(sameOrigin bool) ([]string, error) {
+	rro, err := t.EvaluateScript("Array.prototype.map.call(document.querySelectorAll('a[href]'), function(a) { return a.href; })")
+	if err != nil {
+		return nil, err
+	}
+
+	hrefs, ok := rro.Value.([]interface{})
+	if !ok {
+		return nil, &ScriptEvaluationErr{Message: "links were not an array", ExceptionText: "unable to retrieve links"}
+	}
+
+	var origin *url.URL
+	if sameOrigin {
+		currentUrl, err := t.GetCurrentUrl()
+		if err != nil {
+			return nil, err
+		}
+		if origin, err = url.Parse(currentUrl); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := make(map[string]struct{}, len(hrefs))
+	links := make([]string, 0, len(hrefs))
+	for _, value := range hrefs {
+		href, ok := value.(string)
+		if !ok || href == "" {
+			continue
+		}
+
+		if _, exists := seen[href]; exists {
+			continue
+		}
+		seen[href] = struct{}{}
+
+		if origin != nil {
+			link, err := url.Parse(href)
+			if err != nil || link.Scheme != origin.Scheme || link.Host != origin.Host {
+				continue
+			}
+		}
+		links = append(links, href)
+	}
+	return links, nil
+}
+
+// Returns the top document's images with their absolute src, alt text and natural (intrinsic) size,
+// for example to find images missing alt text. Returns an empty slice if there are no images.
+func (t *Tab) GetImages() ([]ImageInfo, error) {
+	rro, err := t.EvaluateScript(`JSON.stringify(Array.prototype.map.call(document.images, function(img) {
+	return {src: img.src, alt: img.alt, hasAlt: img.hasAttribute('alt'), naturalWidth: img.naturalWidth, naturalHeight: img.naturalHeight};
+}))`)
+	if err != nil {
+		return nil, err
+	}
+
+	imagesJSON, ok := rro.Value.(string)
+	if !ok {
+		return nil, &ScriptEvaluationErr{Message: "images were not a string", ExceptionText: "unable to retrieve images"}
+	}
+
+	images := make([]ImageInfo, 0)
+	if err := json.Unmarshal([]byte(imagesJSON), &images); err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// Makes a request with fetch() from the page so that it is sent with the page's cookies and session,
+// useful for calling an api as the logged in user. headers and body are optional, body is ignored for
+// GET and HEAD requests. Network failures, such as a refused connection or a CORS rejection, are
+// returned as a ScriptEvaluationErr. A response with an error status is not an error.
+func (t *Tab) FetchInPage(url, method string, headers map[string]string, body string) (*FetchResult, error) {
+	if method == "" {
+		method = "GET"
+	}
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+
+	init := map[string]interface{}{
+		"method":      method,
+		"headers":     headers,
+		"credentials": "include",
+	}
+	if body != "" && method != "GET" && method != "HEAD" {
+		init["body"] = body
+	}
+
+	urlJSON, err := json.Marshal(url)
+	if err != nil {
+		return nil, err
+	}
+
+	initJSON, err := json.Marshal(init)
+	if err != nil {
+		return nil, err
+	}
+
+	script := fmt.Sprintf(`(function(url, init) {
+	return fetch(url, init).then(function(response) {
+		var headers = {};
+		response.headers.forEach(function(value, name) { headers[name] = value; });
+		return response.text().then(function(body) {
+			return JSON.stringify({status: response.status, statusText: response.statusText, url: response.url, headers: headers, body: body});
+		});
+	});
+})(%s, %s)`, urlJSON, initJSON)
+
+	rro, err := t.EvaluatePromiseScript(script)
+	if err != nil {
+		if scriptErr, ok := err.(*ScriptEvaluationErr); ok {
+			scriptErr.Message = "fetch failed for " + url + ":"
+		}
+		return nil, err
+	}
+
+	resultJSON, ok := rro.Value.(string)
+	if !ok {
+		return nil, &ScriptEvaluationErr{Message: "fetch result was not a string", ExceptionText: "unable to retrieve fetch result"}
+	}
+
+	result := &FetchResult{}
+	if err := json.Unmarshal([]byte(resultJSON), result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Returns the raw source (non-serialized DOM) of the frame. If you want the visible
+// source, call GetPageSource, passing in the frame's nodeId. Make sure you wait for
+// the element's WaitForReady() to return without error first.
+func (t *Tab) GetFrameSource(frameId, url string) (string, bool, error) {
+	return t.Page.GetResourceContent(frameId, url)
+}
+
+// Gets all frame ids and urls from the top level document.
+func (t *Tab) GetFrameResources() (map[string]string, error) {
+	resources, err := t.Page.GetResourceTree()
+	if err != nil {
+		return nil, err
+	}
+	resourceMap := make(map[string]string)
+	resourceMap[resources.Frame.Id] = resources.Frame.Url
+	recursivelyGetFrameResource(resourceMap, resources)
+	return resourceMap, nil
+}
+
+// Iterate over frame resources and return a map of id => urls
+func recursivelyGetFrameResource(resourceMap map[string]string, resource *gcdapi.PageFrameResourceTree) {
+	for _, frame := range resource.ChildFrames {
+		resourceMap[frame.Frame.Id] = frame.Frame.Url
+		recursivelyGetFrameResource(resourceMap, frame)
+	}
+}
+
+// Returns all frames of the page, top frame first, marking those whose document can be queried from this
+// tab as accessible. Frames running out of process, such as cross origin frames with site isolation, have
+// no content document we can reach so queries against them would only fail.
+func (t *Tab) GetAccessibleFrames() ([]FrameInfo, error) {
+	resources, err := t.Page.GetResourceTree()
+	if err != nil {
+		return nil, err
+	}
+
+	frames := []FrameInfo{{FrameId: resources.Frame.Id, Url: resources.Frame.Url, Accessible: true}}
+	childFrames := resources.ChildFrames
+	for len(childFrames) > 0 {
+		frame := childFrames[0]
+		childFrames = append(childFrames[1:], frame.ChildFrames...)
+		frames = append(frames, FrameInfo{FrameId: frame.Frame.Id, Url: frame.Frame.Url, Accessible: t.isFrameAccessible(frame.Frame.Id)})
+	}
+	return frames, nil
+}
+
+// Returns true if the owner element of frameId has a content document we can query.
+func (t *Tab) isFrameAccessible(frameId string) bool {
+	ownerNodeId, err := t.frameOwnerNodeId(frameId)
+	if err != nil {
+		return false
+	}
+
+	owner, err := t.describeNode(ownerNodeId)
+	if err != nil {
+		return false
+	}
+	return owner.ContentDocument != nil
+}
+
+// Returns all documents as elements that are known.
+func (t *Tab) GetFrameDocuments() []*Element {
+	frames := make([]*Element, 0)
+	t.eleMutex.RLock()
+	for _, ele := range t.elements {
+		if ok, _ := ele.IsDocument(); ok {
+			frames = append(frames, ele)
+		}
+	}
+	t.eleMutex.RUnlock()
+	return frames
+}
+
+// Returns the cookies from the tab.
+func (t *Tab) GetCookies() ([]*gcdapi.NetworkCookie, error) {
+	return t.Page.GetCookies()
+}
+
+// Returns all browser cookies, regardless of the current page. Unlike GetCookies, which only returns
+// cookies that apply to the currently loaded page's url, this includes cookies for other domains and
+// paths. Both include HttpOnly cookies.
+func (t *Tab) GetAllCookies() ([]*gcdapi.NetworkCookie, error) {
+	return t.Network.GetAllCookies()
+}
+
+// Deletes the cookie from the browser
+func (t *Tab) DeleteCookie(cookieName, url string) error {
+	_, err := t.Page.DeleteCookie(cookieName, url)
+	return err
+}
+
+// Sets a cookie for the current page by assigning document.cookie from the page, for when the protocol
+// cookie methods can not be used. Only cookies scripts are allowed to set can be set this way, HttpOnly
+// cookies can not be created or overwritten. Returns an error if the page did not accept the cookie.
+func (t *Tab) SetCookieViaJS(name, value string) error {
+	cookieJSON, err := json.Marshal(name + "=" + value)
+	if err != nil {
+		return err
+	}
+
+	script := fmt.Sprintf(`(function(cookie) {
+	document.cookie = cookie;
+	return document.cookie.split('; ').indexOf(cookie) !== -1;
+})(%s)`, cookieJSON)
+
+	rro, err := t.EvaluateScript(script)
+	if err != nil {
+		return err
+	}
+
+	if set, ok := rro.Value.(bool); !ok || !set {
+		return &ScriptEvaluationErr{Message: "error setting cookie: ", ExceptionText: "the page did not accept cookie " + name}
+	}
+	return nil
+}
+
+// Override the user agent for requests going out and navigator.userAgent. The current document was
+// already served for the previous user agent, navigate or reload to get the markup for the new one.
+func (t *Tab) SetUserAgent(userAgent string) error {
+	_, err := t.Network.SetUserAgentOverride(userAgent)
+	return err
+}
+
+// Clears the user agent override, restoring chrome's default user agent. Like SetUserAgent, it
+// applies to requests made after it is called.
+func (t *Tab) ClearUserAgent() error {
+	return t.SetUserAgent("")
+}
+
+// Overrides the default (white) background color of the frame, used when the page does not set its
+// own. Each component is in the 0-255 range, an alpha of 0 gives a transparent background which is
+// useful for screenshots that are to be composited.
+func (t *Tab) SetBackgroundColorOverride(r, g, b, a int) error {
+	return overridenSetDefaultBackgroundColorOverride(t.ChromeTarget, r, g, b, float64(a)/255)
+}
+
+// Clears the background color override, restoring the default background color.
+func (t *Tab) ClearBackgroundColorOverride() error {
+	_, err := t.Emulation.SetDefaultBackgroundColorOverrideWithParams(&gcdapi.EmulationSetDefaultBackgroundColorOverrideParams{})
+	return err
+}
+
+// Overrides the position reported by navigator.geolocation, accuracy is in meters. The page must
+// still be granted the geolocation permission by the user or browser.
+func (t *Tab) SetGeolocation(latitude, longitude, accuracy float64) error {
+	return overridenSetGeolocationOverride(t.ChromeTarget, latitude, longitude, accuracy)
+}
+
+// Clears the geolocation override, restoring the position of the device.
+func (t *Tab) ClearGeolocation() error {
+	_, err := t.Emulation.ClearGeolocationOverride()
+	return err
+}
+
+// Sets the page scale (pinch zoom) factor, as opposed to the device scale factor of SetDeviceMetricsOverride,
+// for testing zoomed layouts. The page scale applies on top of any viewport override, the viewport size
+// itself is unchanged. Chrome returns an error if the scale is not supported.
+func (t *Tab) SetPageScaleFactor(scale float64) error {
+	t.emulationMutex.Lock()
+	defer t.emulationMutex.Unlock()
+
+	_, err := t.Emulation.SetPageScaleFactor(scale)
+	return err
+}
+
+// Enables or disables touch emulation, so pages see touch support such as 'ontouchstart' in window
+// and receive the events of Tap.
+func (t *Tab) SetTouchEmulation(enabled bool) error {
+	maxTouchPoints := 1
+	if enabled {
+		maxTouchPoints = 5
+	}
+
+	t.emulationMutex.Lock()
+	defer t.emulationMutex.Unlock()
+
+	_, err := t.Emulation.SetTouchEmulationEnabled(enabled, maxTouchPoints)
+	return err
+}
+
+// Overrides the viewport size in css pixels, device scale factor and mobile flag the page sees, which
+// also applies to media queries, for testing responsive layouts. Mobile pages use their meta viewport
+// rather than the width for layout. See EmulateDevice to emulate a preset with touch and user agent.
+func (t *Tab) SetDeviceMetrics(width, height int, deviceScaleFactor float64, mobile bool) error {
+	device := &Device{Width: width, Height: height, DeviceScaleFactor: deviceScaleFactor, Mobile: mobile}
+
+	t.emulationMutex.Lock()
+	defer t.emulationMutex.Unlock()
+
+	if _, err := t.Emulation.SetDeviceMetricsOverrideWithParams(device.metricsParams()); err != nil {
+		return err
+	}
+	t.emulatedDevice = device
+	return nil
+}
+
+// Clears the device metrics override of SetDeviceMetrics or EmulateDevice, restoring the window's viewport.
+func (t *Tab) ClearDeviceMetrics() error {
+	t.emulationMutex.Lock()
+	defer t.emulationMutex.Unlock()
+
+	if _, err := t.Emulation.ClearDeviceMetricsOverride(); err != nil {
+		return err
+	}
+	t.emulatedDevice = nil
+	return nil
+}
+
+// Emulates one of the DevicePresets by name, setting the viewport, device scale factor, mobile flag,
+// touch emulation and user agent together. Returns UnknownDeviceErr if there is no such preset.
+func (t *Tab) EmulateDevice(name string) error {
+	device, ok := DevicePresets[name]
+	if !ok {
+		return &UnknownDeviceErr{Message: name}
+	}
+
+	t.emulationMutex.Lock()
+	defer t.emulationMutex.Unlock()
+
+	if _, err := t.Emulation.SetDeviceMetricsOverrideWithParams(device.metricsParams()); err != nil {
+		return err
+	}
+	t.emulatedDevice = device
+
+	if _, err := t.Emulation.SetTouchEmulationEnabled(device.Touch, device.maxTouchPoints()); err != nil {
+		return err
+	}
+	return t.SetUserAgent(device.UserAgent)
+}
+
+// Emulates the prefers-reduced-motion css media feature as "reduce" when enabled, or "no-preference"
+// when not, for accessibility testing and stable screenshots. Persists for the tab alongside any other
+// emulated media features.
+func (t *Tab) SetReducedMotion(enabled bool) error {
+	value := "no-preference"
+	if enabled {
+		value = "reduce"
+	}
+	return t.setMediaFeature("prefers-reduced-motion", value)
+}
+
+// Sets an emulated css media feature, sending all features set for the tab as each call replaces them.
+func (t *Tab) setMediaFeature(name, value string) error {
+	t.emulationMutex.Lock()
+	defer t.emulationMutex.Unlock()
+
+	features := make(map[string]string, len(t.mediaFeatures)+1)
+	for k, v := range t.mediaFeatures {
+		features[k] = v
+	}
+	features[name] = value
+
+	if err := overridenSetEmulatedMedia(t.ChromeTarget, "", features); err != nil {
+		return err
+	}
+	t.mediaFeatures = features
+	return nil
+}
+
+// Clears the emulation overrides set on the tab, device metrics, touch, user agent, page scale
+// factor, media features, background color, CPU throttling, network conditions and geolocation,
+// so the tab can be reused with a clean slate. All overrides are cleared even if some fail, returning an AggregateErr
+// of the failures.
+func (t *Tab) ResetEmulation() error {
+	t.emulationMutex.Lock()
+	defer t.emulationMutex.Unlock()
+
+	errs := make([]error, 0)
+	if _, err := t.Emulation.ClearDeviceMetricsOverride(); err != nil {
+		errs = append(errs, fmt.Errorf("device metrics: %s", err))
+	}
+	if _, err := t.Emulation.SetTouchEmulationEnabled(false, 1); err != nil {
+		errs = append(errs, fmt.Errorf("touch: %s", err))
+	}
+	if err := t.ClearUserAgent(); err != nil {
+		errs = append(errs, fmt.Errorf("user agent: %s", err))
+	}
+	if _, err := t.Emulation.SetPageScaleFactor(1); err != nil {
+		errs = append(errs, fmt.Errorf("page scale factor: %s", err))
+	}
+	if err := overridenSetEmulatedMedia(t.ChromeTarget, "", nil); err != nil {
+		errs = append(errs, fmt.Errorf("media: %s", err))
+	}
+	if err := t.ClearBackgroundColorOverride(); err != nil {
+		errs = append(errs, fmt.Errorf("background color: %s", err))
+	}
+	if _, err := t.Emulation.SetCPUThrottlingRate(1); err != nil {
+		errs = append(errs, fmt.Errorf("cpu throttling: %s", err))
+	}
+	if err := t.ClearNetworkConditions(); err != nil {
+		errs = append(errs, fmt.Errorf("network conditions: %s", err))
+	}
+	if err := t.ClearGeolocation(); err != nil {
+		errs = append(errs, fmt.Errorf("geolocation: %s", err))
+	}
+	t.mediaFeatures = nil
+	t.emulatedDevice = nil
+
+	if len(errs) > 0 {
+		return &AggregateErr{Message: "failed to reset emulation", Errors: errs}
+	}
+	return nil
+}
+
+// Returns the latest security state of the page; secure, insecure, neutral, info or unknown. The Security
+// debugger service is enabled on first call, which reports the current state. Returns a TimeoutErr if
+// chrome does not report a state.
+func (t *Tab) GetSecurityState() (string, error) {
+	event, err := t.getSecurityState()
+	if err != nil {
+		return "", err
+	}
+	return event.Params.SecurityState, nil
+}
+
+// Returns the subject, issuer and validity of the certificate the page was served with, taken from the
+// security state explanations. Returns CertificateNotFoundErr for pages not served over https.
+func (t *Tab) GetCertificateInfo() (*CertInfo, error) {
+	event, err := t.getSecurityState()
+	if err != nil {
+		return nil, err
+	}
+
+	if !event.Params.SchemeIsCryptographic {
+		return nil, &CertificateNotFoundErr{Message: "page was not served over a secure scheme"}
+	}
+	return certInfoFromExplanations(event.Params.Explanations)
+}
+
+// Parses the first (leaf) certificate found in the security state explanations. Certificates are
+// base64 encoded DER.
+func certInfoFromExplanations(explanations []*gcdapi.SecuritySecurityStateExplanation) (*CertInfo, error) {
+	for _, explanation := range explanations {
+		if len(explanation.Certificate) == 0 {
+			continue
+		}
+
+		der, err := base64.StdEncoding.DecodeString(explanation.Certificate[0])
+		if err != nil {
+			return nil, err
+		}
+
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		return &CertInfo{Subject: cert.Subject.String(), Issuer: cert.Issuer.String(), ValidFrom: cert.NotBefore, ValidTo: cert.NotAfter}, nil
+	}
+	return nil, &CertificateNotFoundErr{Message: "no certificate in security state explanations"}
+}
+
+// Returns the latest security state changed event, enabling the Security service and waiting for the
+// first state if necessary.
+func (t *Tab) getSecurityState() (*gcdapi.SecuritySecurityStateChangedEvent, error) {
+	if err := t.enableSecurity(); err != nil {
+		return nil, err
+	}
+
+	var event *gcdapi.SecuritySecurityStateChangedEvent
+	err := t.WaitFor(50*time.Millisecond, t.elementTimeout, func(tab *Tab) bool {
+		tab.securityMutex.RLock()
+		event = tab.securityState
+		tab.securityMutex.RUnlock()
+		return event != nil
+	})
+	if err != nil {
+		return nil, &TimeoutErr{Message: "waiting for security state"}
+	}
+	return event, nil
+}
+
+// Enables the Security debugger service and subscribes to security state changes, does nothing if we
+// have already done so.
+func (t *Tab) enableSecurity() error {
+	t.securityMutex.Lock()
+	defer t.securityMutex.Unlock()
+
+	if t.securityEnabled {
+		return nil
+	}
+
+	t.subscribeSecurityStateChanged()
+	if _, err := t.Security.Enable(); err != nil {
+		t.Unsubscribe("Security.securityStateChanged")
+		return err
+	}
+	t.securityEnabled = true
+	return nil
+}
+
+// Registers chrome to start retrieving console messages, caller must pass in call back
+// function to handle it.
+func (t *Tab) GetConsoleMessages(messageHandler ConsoleMessageFunc) {
+	t.Subscribe("Console.messageAdded", t.defaultConsoleMessageAdded(messageHandler))
+}
+
+// Like GetConsoleMessages but messageHandler is only called for messages whose level is one of levels,
+// such as "error" and "warning" for noisy pages.
+func (t *Tab) GetConsoleMessagesFiltered(levels []string, messageHandler ConsoleMessageFunc) {
+	allowed := make(map[string]struct{}, len(levels))
+	for _, level := range levels {
+		allowed[level] = struct{}{}
+	}
+
+	t.Subscribe("Console.messageAdded", t.defaultConsoleMessageAdded(func(tab *Tab, message *gcdapi.ConsoleConsoleMessage) {
+		if _, ok := allowed[message.Level]; ok {
+			messageHandler(tab, message)
+		}
+	}))
+}
+
+// Stops the debugger service from sending console messages and closes the channel
+// Pass shouldDisable as true if you wish to disable Console debugger
+func (t *Tab) StopConsoleMessages(shouldDisable bool) error {
+	var err error
+	t.Unsubscribe("Console.messageAdded")
+	if shouldDisable {
+		_, err = t.Console.Disable()
+	}
+	return err
+}
+
+// Registers exceptionHandler to be called for each uncaught exception or unhandled promise rejection
+// thrown by the page, even if nothing was logged to the console. Pass nil to stop receiving exceptions.
+func (t *Tab) GetRuntimeExceptions(exceptionHandler RuntimeExceptionFunc) {
+	if exceptionHandler == nil {
+		t.Unsubscribe("Runtime.exceptionThrown")
+		return
+	}
+	t.Subscribe("Runtime.exceptionThrown", t.defaultExceptionThrown(exceptionHandler))
+}
+
+// Registers consoleFn to be called for each console API call (console.log, console.error etc) with the
+// call type as level and the raw argument objects, preserving objects rather than their string form.
+// Pass nil to stop receiving console API calls.
+func (t *Tab) OnConsole(consoleFn ConsoleAPIFunc) {
+	if consoleFn == nil {
+		t.Unsubscribe("Runtime.consoleAPICalled")
+		return
+	}
+
+	t.Subscribe("Runtime.consoleAPICalled", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.RuntimeConsoleAPICalledEvent{}
+		if err := json.Unmarshal(payload, message); err == nil {
+			consoleFn(t, message.Params.Type, message.Params.Args, message.Params.StackTrace)
+		}
+	})
+}
+
+// Registers timelineFn to be called for each performance timeline event of eventTypes, such as
+// "largest-contentful-paint" and "layout-shift" for measuring Core Web Vitals. Events which were
+// buffered before this was called are reported as well. Supported types depend on the chrome version,
+// older versions without the PerformanceTimeline domain return an error.
+func (t *Tab) CollectPerformanceTimeline(eventTypes []string, timelineFn TimelineEventFunc) error {
+	t.Subscribe("PerformanceTimeline.timelineEventAdded", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &timelineEventAddedEvent{}
+		if err := json.Unmarshal(payload, message); err == nil && message.Params.Event != nil {
+			timelineFn(t, message.Params.Event)
+		}
+	})
+
+	if err := overridenPerformanceTimelineEnable(t.ChromeTarget, eventTypes); err != nil {
+		t.Unsubscribe("PerformanceTimeline.timelineEventAdded")
+		return err
+	}
+	return nil
+}
+
+// Stops reporting performance timeline events.
+func (t *Tab) StopPerformanceTimeline() error {
+	t.Unsubscribe("PerformanceTimeline.timelineEventAdded")
+	return overridenPerformanceTimelineEnable(t.ChromeTarget, []string{})
+}
+
+// Listens to network traffic, each handler can be nil in which case we'll only call the handlers defined.
+// Outstanding requests are tracked once this has been called, even if all handlers are nil.
+func (t *Tab) GetNetworkTraffic(requestHandlerFn NetworkRequestHandlerFunc, responseHandlerFn NetworkResponseHandlerFunc, finishedHandlerFn NetworkFinishedHandlerFunc) error {
+	t.networkMutex.Lock()
+	t.requestHandler = requestHandlerFn
+	t.responseHandler = responseHandlerFn
+	t.finishedHandler = finishedHandlerFn
+	t.networkMutex.Unlock()
+
+	return t.enableNetwork()
+}
+
+// Stops calling the network handlers. Pass shouldDisable as true if you wish to disable the network
+// service, this also unsubscribes from network events and stops tracking outstanding requests.
+func (t *Tab) StopNetworkTraffic(shouldDisable bool) error {
+	t.networkMutex.Lock()
+	defer t.networkMutex.Unlock()
+
+	t.requestHandler = nil
+	t.responseHandler = nil
+	t.finishedHandler = nil
+
+	if !shouldDisable || !t.networkEnabled {
+		return nil
+	}
+
+	t.Unsubscribe("Network.requestWillBeSent")
+	t.Unsubscribe("Network.responseReceived")
+	t.Unsubscribe("Network.loadingFinished")
+	t.Unsubscribe("Network.loadingFailed")
+	t.requests = make(map[string]*NetworkRequest)
+	t.finishedRequests = make(map[string]time.Time)
+	t.networkEnabled = false
+
+	_, err := t.Network.Disable()
+	return err
+}
+
+// Collects the response bodies of all requests whose url matches the urlPattern regular expression
+// as they finish loading, retrievable via GetCollectedBodies. Calling it again replaces the pattern.
+// Collected bodies are kept until ClearCollectedBodies is called.
+func (t *Tab) CollectResponseBodies(urlPattern string) error {
+	bodyPattern, err := regexp.Compile(urlPattern)
+	if err != nil {
+		return err
+	}
+
+	if err := t.enableNetwork(); err != nil {
+		return err
+	}
+
+	t.networkMutex.Lock()
+	t.bodyPattern = bodyPattern
+	t.networkMutex.Unlock()
+	return nil
+}
+
+// Stops collecting response bodies, bodies already collected are still returned by GetCollectedBodies.
+func (t *Tab) StopCollectingResponseBodies() {
+	t.networkMutex.Lock()
+	t.bodyPattern = nil
+	t.networkMutex.Unlock()
+}
+
+// Returns a copy of the collected response bodies keyed by url. If a url was requested more than
+// once, the most recent body is returned.
+func (t *Tab) GetCollectedBodies() map[string][]byte {
+	t.networkMutex.RLock()
+	defer t.networkMutex.RUnlock()
+
+	bodies := make(map[string][]byte, len(t.collectedBodies))
+	for url, body := range t.collectedBodies {
+		bodies[url] = body
+	}
+	return bodies
+}
+
+// Removes all collected response bodies, such as between pages of a long running crawl so they
+// do not accumulate. Collection continues if CollectResponseBodies is still active.
+func (t *Tab) ClearCollectedBodies() {
+	t.networkMutex.Lock()
+	t.collectedBodies = make(map[string][]byte)
+	t.networkMutex.Unlock()
+}
+
+// Returns the response body of a request by its requestId, such as the JSON of an XHR, and whether
+// the body is base64 encoded (binary responses). Call it once the request has finished, see the
+// finished handler of GetNetworkTraffic. Chrome only keeps bodies while they fit in its buffers.
+func (t *Tab) GetResponseBody(requestId string) (string, bool, error) {
+	return t.Network.GetResponseBody(requestId)
+}
+
+// Retrieves the response body of a finished request and stores it if we are still collecting.
+func (t *Tab) collectResponseBody(requestId, url string) {
+	body, base64Encoded, err := t.GetResponseBody(requestId)
+	if err != nil {
+		t.debugf("unable to collect response body of %s: %s\n", url, err)
+		return
+	}
+
+	bodyBytes := []byte(body)
+	if base64Encoded {
+		if bodyBytes, err = base64.StdEncoding.DecodeString(body); err != nil {
+			t.debugf("unable to decode response body of %s: %s\n", url, err)
+			return
+		}
 	}
 
-	if _, err := t.Input.DispatchMouseEventWithParams(mouseReleasedParams); err != nil {
-		return err
+	t.networkMutex.Lock()
+	if t.bodyPattern != nil {
+		t.collectedBodies[url] = bodyBytes
 	}
-	return nil
+	t.networkMutex.Unlock()
 }
 
-// Issues a double click on the x, y coords provided.
-func (t *Tab) DoubleClick(x, y float64) error {
-	return t.click(x, y, 2)
+// Returns the number of requests that have been sent but have not yet finished or failed.
+// Requests are only tracked after GetNetworkTraffic has been called.
+func (t *Tab) InFlightRequestCount() int {
+	t.networkMutex.RLock()
+	defer t.networkMutex.RUnlock()
+	return len(t.requests)
 }
 
-// Moves the mouse to the x, y coords provided.
-func (t *Tab) MoveMouse(x, y float64) error {
-	mouseMovedParams := &gcdapi.InputDispatchMouseEventParams{TheType: "mouseMoved",
-		X: x,
-		Y: y,
+// Returns the requests that have been sent but have not yet finished or failed, ordered by the time they
+// were sent, for finding out which requests a page is stuck on. Requests are only tracked after
+// GetNetworkTraffic has been called.
+func (t *Tab) GetPendingRequests() []*NetworkRequest {
+	t.networkMutex.RLock()
+	pending := make([]*NetworkRequest, 0, len(t.requests))
+	for _, request := range t.requests {
+		pending = append(pending, request)
 	}
+	t.networkMutex.RUnlock()
 
-	_, err := t.Input.DispatchMouseEventWithParams(mouseMovedParams)
-	return err
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Timestamp < pending[j].Timestamp })
+	return pending
 }
 
-// Sends keystrokes to whatever is focused, best called from Element.SendKeys which will
-// try to focus on the element first. Use \n for Enter, \b for backspace or \t for Tab.
-func (t *Tab) SendKeys(text string) error {
-	inputParams := &gcdapi.InputDispatchKeyEventParams{TheType: "char"}
+// Enables the Network debugger service and subscribes to network events, does nothing
+// if we have already done so.
+func (t *Tab) enableNetwork() error {
+	t.networkMutex.Lock()
+	defer t.networkMutex.Unlock()
 
-	// loop over input, looking for system keys and handling them
-	for _, inputchar := range text {
-		input := string(inputchar)
+	if t.networkEnabled {
+		return nil
+	}
 
-		// check system keys
-		switch input {
-		case "\r", "\n", "\t", "\b":
-			if err := t.pressSystemKey(input); err != nil {
-				return err
-			}
-			continue
-		}
-		inputParams.Text = input
-		_, err := t.Input.DispatchKeyEventWithParams(inputParams)
-		if err != nil {
+	if _, err := t.Network.Enable(maximumTotalBufferSize, maximumResourceBufferSize); err != nil {
+		return err
+	}
+	t.subscribeNetworkEvents()
+	t.networkEnabled = true
+
+	if t.acceptLanguage != "" {
+		if _, err := t.Network.SetExtraHTTPHeaders(map[string]interface{}{"Accept-Language": t.acceptLanguage}); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// Super ghetto, i know.
-func (t *Tab) pressSystemKey(systemKey string) error {
-	inputParams := &gcdapi.InputDispatchKeyEventParams{TheType: "rawKeyDown"}
-
-	switch systemKey {
-	case "\b":
-		inputParams.UnmodifiedText = "\b"
-		inputParams.Text = "\b"
-		inputParams.WindowsVirtualKeyCode = 8
-		inputParams.NativeVirtualKeyCode = 8
-	case "\t":
-		inputParams.UnmodifiedText = "\t"
-		inputParams.Text = "\t"
-		inputParams.WindowsVirtualKeyCode = 9
-		inputParams.NativeVirtualKeyCode = 9
-	case "\r", "\n":
-		inputParams.UnmodifiedText = "\r"
-		inputParams.Text = "\r"
-		inputParams.WindowsVirtualKeyCode = 13
-		inputParams.NativeVirtualKeyCode = 13
+// Sets the Accept-Language header sent with all requests from this tab and overrides navigator.language
+// and navigator.languages to match, so the site sees a consistent locale. lang is a header value such as
+// "fr-FR,fr;q=0.9". The navigator override applies to the current document and all subsequently loaded
+// documents. Calling it again replaces the previous language. Note chrome only keeps one set of extra
+// HTTP headers, so this replaces any set directly with Network.SetExtraHTTPHeaders.
+func (t *Tab) SetAcceptLanguage(lang string) error {
+	if err := t.enableNetwork(); err != nil {
+		return err
 	}
 
-	if _, err := t.Input.DispatchKeyEventWithParams(inputParams); err != nil {
+	if _, err := t.Network.SetExtraHTTPHeaders(map[string]interface{}{"Accept-Language": lang}); err != nil {
 		return err
 	}
 
-	inputParams.TheType = "char"
-	if _, err := t.Input.DispatchKeyEventWithParams(inputParams); err != nil {
+	t.networkMutex.Lock()
+	t.acceptLanguage = lang
+	t.networkMutex.Unlock()
+
+	languages := make([]string, 0)
+	for _, language := range strings.Split(lang, ",") {
+		language = strings.TrimSpace(strings.Split(language, ";")[0])
+		if language != "" {
+			languages = append(languages, language)
+		}
+	}
+	languagesJSON, err := json.Marshal(languages)
+	if err != nil {
 		return err
 	}
 
-	inputParams.TheType = "keyUp"
-	if _, err := t.Input.DispatchKeyEventWithParams(inputParams); err != nil {
+	script := fmt.Sprintf(`(function(languages) {
+	Object.defineProperty(navigator, 'language', {configurable: true, get: function() { return languages[0]; }});
+	Object.defineProperty(navigator, 'languages', {configurable: true, get: function() { return languages; }});
+})(%s);`, languagesJSON)
+
+	scriptId, err := t.InjectScriptOnLoad(script)
+	if err != nil {
 		return err
 	}
-	return nil
-}
 
-// Injects custom javascript prior to the page loading on all frames. Returns scriptId which
-// can be used to remove the script. If you only want the script to interact with the top
-// document, you'll need to do checks in the injected script such as testing location.href.
-//
-// Alternatively, you can use Tab.EvaluateScript to only work on the global context.
-func (t *Tab) InjectScriptOnLoad(scriptSource string) (string, error) {
-	scriptId, err := t.Page.AddScriptToEvaluateOnLoad(scriptSource)
-	if err != nil {
-		return "", err
+	// swap in our script, each call removes the script it replaced.
+	t.networkMutex.Lock()
+	previousScriptId := t.languageScriptId
+	t.languageScriptId = scriptId
+	t.networkMutex.Unlock()
+
+	if previousScriptId != "" {
+		if err := t.RemoveScriptFromOnLoad(previousScriptId); err != nil {
+			return err
+		}
 	}
-	return scriptId, nil
-}
 
-// Removes the script by the scriptId.
-func (t *Tab) RemoveScriptFromOnLoad(scriptId string) error {
-	_, err := t.Page.RemoveScriptToEvaluateOnLoad(scriptId)
+	_, err = t.EvaluateScript(script)
 	return err
 }
 
-// Evaluates script in the global context.
-func (t *Tab) EvaluateScript(scriptSource string) (*gcdapi.RuntimeRemoteObject, error) {
-	return t.evaluateScript(scriptSource, false)
-}
+// Answers proxy authentication challenges with username and password by intercepting all requests.
+// Chrome 64 has no Fetch domain, so this uses Network request interception and its authRequired
+// challenges; authentication challenges from sites (not the proxy) are left to chrome's default
+// handling. Pass an empty username to stop answering proxy challenges.
+func (t *Tab) SetProxyAuth(username, password string) error {
+	t.interceptMutex.Lock()
+	t.proxyUsername = username
+	t.proxyPassword = password
+	t.interceptMutex.Unlock()
 
-// Evaluates script in the global context.
-func (t *Tab) EvaluatePromiseScript(scriptSource string) (*gcdapi.RuntimeRemoteObject, error) {
-	return t.evaluateScript(scriptSource, true)
+	return t.updateRequestInterception()
 }
 
-// Evaluates script in the global context.
-func (t *Tab) evaluateScript(scriptSource string, awaitPromise bool) (*gcdapi.RuntimeRemoteObject, error) {
-	objectGroup := "autogcd"
-	includeCommandLineAPI := true
-	contextId := 0
-	silent := true
-	returnByValue := true
-	generatePreview := true
-	userGestures := true
-	rro, exception, err := overridenRuntimeEvaluate(t.ChromeTarget, scriptSource, objectGroup, includeCommandLineAPI, silent, contextId, returnByValue, generatePreview, userGestures, awaitPromise)
-	if err != nil {
-		return nil, err
-	}
-	if exception != nil {
-		return nil, &ScriptEvaluationErr{Message: "error executing script: ", ExceptionText: exception.Text, ExceptionDetails: exception}
-	}
-	return rro, nil
-}
+// Intercepts requests matching any of the patterns, or all requests if none are given, passing each to
+// interceptFn which must continue or abort it via tab.Network.ContinueInterceptedRequestWithParams. Auth
+// challenges are not passed to interceptFn, see SetProxyAuth. While proxy auth is set, all requests are
+// intercepted regardless of patterns. Pass a nil interceptFn to stop intercepting.
+func (t *Tab) SetRequestInterception(interceptFn RequestInterceptedFunc, patterns ...*InterceptPattern) error {
+	t.interceptMutex.Lock()
+	t.interceptHandler = interceptFn
+	t.interceptPatterns = patterns
+	t.interceptMutex.Unlock()
 
-// Takes a screenshot of the currently loaded page (only the dimensions visible in browser window)
-func (t *Tab) GetScreenShot() ([]byte, error) {
-	var imgBytes []byte
+	return t.updateRequestInterception()
+}
 
-	params := &gcdapi.PageCaptureScreenshotParams{
-		Format:  "png",
-		Quality: 100,
-	}
+// Continues an intercepted request unmodified, for use in a SetRequestInterception handler.
+func (t *Tab) ContinueInterceptedRequest(interceptionId string) error {
+	_, err := t.Network.ContinueInterceptedRequestWithParams(&gcdapi.NetworkContinueInterceptedRequestParams{InterceptionId: interceptionId})
+	return err
+}
 
-	img, err := t.Page.CaptureScreenshotWithParams(params)
-	if err != nil {
-		return nil, err
-	}
-	imgBytes, err = base64.StdEncoding.DecodeString(img)
-	if err != nil {
-		return nil, err
+// Aborts an intercepted request so it fails with errorReason, such as Failed, Aborted, BlockedByClient or
+// AccessDenied, for use in a SetRequestInterception handler. errorReason defaults to BlockedByClient.
+func (t *Tab) AbortInterceptedRequest(interceptionId, errorReason string) error {
+	if errorReason == "" {
+		errorReason = "BlockedByClient"
 	}
-	return imgBytes, nil
+	_, err := t.Network.ContinueInterceptedRequestWithParams(&gcdapi.NetworkContinueInterceptedRequestParams{InterceptionId: interceptionId, ErrorReason: errorReason})
+	return err
 }
 
-// Returns the top document title
-func (t *Tab) GetTitle() (string, error) {
-	var title string
-	var ok bool
-
-	resp, err := t.EvaluateScript("window.top.document.title")
-	if err != nil {
-		return "", err
+// Emulates network conditions such as a slow 3G link, latency is the added round trip time in milliseconds
+// and the throughputs are in bytes per second, -1 disables throttling. If offline is true all requests fail.
+// Call ClearNetworkConditions to restore the real network.
+func (t *Tab) SetNetworkConditions(offline bool, latencyMs, downloadThroughput, uploadThroughput float64) error {
+	if err := t.enableNetwork(); err != nil {
+		return err
 	}
 
-	if title, ok = resp.Value.(string); !ok {
-		return "", &ScriptEvaluationErr{Message: "title was not a string", ExceptionText: "unable to retrieve document title, was not a string"}
+	params := &gcdapi.NetworkEmulateNetworkConditionsParams{
+		Offline:            offline,
+		Latency:            latencyMs,
+		DownloadThroughput: downloadThroughput,
+		UploadThroughput:   uploadThroughput,
 	}
-	return title, nil
+	_, err := t.Network.EmulateNetworkConditionsWithParams(params)
+	return err
 }
 
-// Returns the raw source (non-serialized DOM) of the frame. If you want the visible
-// source, call GetPageSource, passing in the frame's nodeId. Make sure you wait for
-// the element's WaitForReady() to return without error first.
-func (t *Tab) GetFrameSource(frameId, url string) (string, bool, error) {
-	return t.Page.GetResourceContent(frameId, url)
+// Emulates the network being offline so all requests fail.
+func (t *Tab) SetOffline() error {
+	return t.SetNetworkConditions(true, 0, -1, -1)
 }
 
-// Gets all frame ids and urls from the top level document.
-func (t *Tab) GetFrameResources() (map[string]string, error) {
-	resources, err := t.Page.GetResourceTree()
-	if err != nil {
-		return nil, err
-	}
-	resourceMap := make(map[string]string)
-	resourceMap[resources.Frame.Id] = resources.Frame.Url
-	recursivelyGetFrameResource(resourceMap, resources)
-	return resourceMap, nil
+// Clears the emulated network conditions of SetNetworkConditions or SetOffline.
+func (t *Tab) ClearNetworkConditions() error {
+	return t.SetNetworkConditions(false, 0, -1, -1)
 }
 
-// Iterate over frame resources and return a map of id => urls
-func recursivelyGetFrameResource(resourceMap map[string]string, resource *gcdapi.PageFrameResourceTree) {
-	for _, frame := range resource.ChildFrames {
-		resourceMap[frame.Frame.Id] = frame.Frame.Url
-		recursivelyGetFrameResource(resourceMap, frame)
+// Blocks requests whose url matches any of the patterns, where * matches any characters, such as
+// "*.doubleclick.net/*". Blocked requests fail with BlockedByClient. Pass no patterns to stop blocking.
+func (t *Tab) BlockURLs(patterns []string) error {
+	if patterns == nil {
+		patterns = []string{}
 	}
-}
 
-// Returns all documents as elements that are known.
-func (t *Tab) GetFrameDocuments() []*Element {
-	frames := make([]*Element, 0)
-	t.eleMutex.RLock()
-	for _, ele := range t.elements {
-		if ok, _ := ele.IsDocument(); ok {
-			frames = append(frames, ele)
-		}
+	if err := t.enableNetwork(); err != nil {
+		return err
 	}
-	t.eleMutex.RUnlock()
-	return frames
-}
-
-// Returns the cookies from the tab.
-func (t *Tab) GetCookies() ([]*gcdapi.NetworkCookie, error) {
-	return t.Page.GetCookies()
-}
 
-// Deletes the cookie from the browser
-func (t *Tab) DeleteCookie(cookieName, url string) error {
-	_, err := t.Page.DeleteCookie(cookieName, url)
+	_, err := t.Network.SetBlockedURLs(patterns)
 	return err
 }
 
-// Override the user agent for requests going out.
-func (t *Tab) SetUserAgent(userAgent string) error {
-	_, err := t.Network.SetUserAgentOverride(userAgent)
-	return err
-}
+// Enables request interception with the patterns required by the interception features in use, and
+// disables it if none are.
+func (t *Tab) updateRequestInterception() error {
+	t.interceptMutex.Lock()
+	defer t.interceptMutex.Unlock()
 
-// Registers chrome to start retrieving console messages, caller must pass in call back
-// function to handle it.
-func (t *Tab) GetConsoleMessages(messageHandler ConsoleMessageFunc) {
-	t.Subscribe("Console.messageAdded", t.defaultConsoleMessageAdded(messageHandler))
-}
+	shouldIntercept := t.proxyUsername != "" || t.interceptHandler != nil
+	if !shouldIntercept {
+		if !t.interceptEnabled {
+			return nil
+		}
+		if err := overridenSetRequestInterception(t.ChromeTarget, []map[string]interface{}{}); err != nil {
+			return err
+		}
+		t.Unsubscribe("Network.requestIntercepted")
+		t.interceptEnabled = false
+		return nil
+	}
 
-// Stops the debugger service from sending console messages and closes the channel
-// Pass shouldDisable as true if you wish to disable Console debugger
-func (t *Tab) StopConsoleMessages(shouldDisable bool) error {
-	var err error
-	t.Unsubscribe("Console.messageAdded")
-	if shouldDisable {
-		_, err = t.Console.Disable()
+	patterns := []map[string]interface{}{{"urlPattern": "*"}}
+	if t.proxyUsername == "" && len(t.interceptPatterns) > 0 {
+		patterns = make([]map[string]interface{}, len(t.interceptPatterns))
+		for i, pattern := range t.interceptPatterns {
+			patterns[i] = pattern.params()
+		}
 	}
-	return err
-}
 
-// Listens to network traffic, each handler can be nil in which case we'll only call the handlers defined.
-func (t *Tab) GetNetworkTraffic(requestHandlerFn NetworkRequestHandlerFunc, responseHandlerFn NetworkResponseHandlerFunc, finishedHandlerFn NetworkFinishedHandlerFunc) error {
-	if requestHandlerFn == nil && responseHandlerFn == nil && finishedHandlerFn == nil {
-		return nil
+	if t.interceptEnabled {
+		return overridenSetRequestInterception(t.ChromeTarget, patterns)
 	}
-	_, err := t.Network.Enable(maximumTotalBufferSize, maximumResourceBufferSize)
-	if err != nil {
+
+	if err := t.enableNetwork(); err != nil {
 		return err
 	}
 
-	if requestHandlerFn != nil {
-		t.Subscribe("Network.requestWillBeSent", func(target *gcd.ChromeTarget, payload []byte) {
-			message := &gcdapi.NetworkRequestWillBeSentEvent{}
-			if err := json.Unmarshal(payload, message); err == nil {
-				p := message.Params
-				request := &NetworkRequest{RequestId: p.RequestId, FrameId: p.FrameId, LoaderId: p.LoaderId, DocumentURL: p.DocumentURL, Request: p.Request, Timestamp: p.Timestamp, Initiator: p.Initiator, RedirectResponse: p.RedirectResponse, Type: p.Type}
-				requestHandlerFn(t, request)
-			}
-		})
+	t.subscribeRequestIntercepted()
+	if err := overridenSetRequestInterception(t.ChromeTarget, patterns); err != nil {
+		t.Unsubscribe("Network.requestIntercepted")
+		return err
 	}
+	t.interceptEnabled = true
+	return nil
+}
 
-	if responseHandlerFn != nil {
-		t.Subscribe("Network.responseReceived", func(target *gcd.ChromeTarget, payload []byte) {
-			message := &gcdapi.NetworkResponseReceivedEvent{}
-			if err := json.Unmarshal(payload, message); err == nil {
-				p := message.Params
-				response := &NetworkResponse{RequestId: p.RequestId, FrameId: p.FrameId, LoaderId: p.LoaderId, Response: p.Response, Timestamp: p.Timestamp, Type: p.Type}
-				responseHandlerFn(t, response)
-			}
-		})
+// Dispatches an intercepted request. Auth challenges are answered here, proxy challenges with our proxy
+// credentials and all others with chrome's default behavior. Other requests are given to the caller's
+// interception handler, or continued if there is none.
+func (t *Tab) handleRequestIntercepted(message *gcdapi.NetworkRequestInterceptedEvent) {
+	params := &gcdapi.NetworkContinueInterceptedRequestParams{InterceptionId: message.Params.InterceptionId}
+
+	t.interceptMutex.RLock()
+	interceptFn := t.interceptHandler
+	if challenge := message.Params.AuthChallenge; challenge != nil {
+		interceptFn = nil
+		params.AuthChallengeResponse = &gcdapi.NetworkAuthChallengeResponse{Response: "Default"}
+		if challenge.Source == "Proxy" && t.proxyUsername != "" {
+			params.AuthChallengeResponse = &gcdapi.NetworkAuthChallengeResponse{Response: "ProvideCredentials", Username: t.proxyUsername, Password: t.proxyPassword}
+		}
 	}
+	t.interceptMutex.RUnlock()
 
-	if finishedHandlerFn != nil {
-		t.Subscribe("Network.loadingFinished", func(target *gcd.ChromeTarget, payload []byte) {
-			message := &gcdapi.NetworkLoadingFinishedEvent{}
-			if err := json.Unmarshal(payload, message); err == nil {
-				p := message.Params
-				finishedHandlerFn(t, p.RequestId, p.EncodedDataLength, p.Timestamp)
-			}
-		})
+	if interceptFn != nil {
+		interceptFn(t, message)
+		return
 	}
-	return nil
-}
 
-// Unsubscribes from network request/response events and disables the Network debugger.
-// Pass shouldDisable as true if you wish to disable the network service.
-func (t *Tab) StopNetworkTraffic(shouldDisable bool) error {
-	var err error
-	t.Unsubscribe("Network.requestWillBeSent")
-	t.Unsubscribe("Network.responseReceived")
-	t.Unsubscribe("Network.loadingFinished")
-	if shouldDisable {
-		_, err = t.Network.Disable()
+	if _, err := t.Network.ContinueInterceptedRequestWithParams(params); err != nil {
+		t.debugf("unable to continue intercepted request %s: %s\n", message.Params.InterceptionId, err)
 	}
-	return err
 }
 
 // Listens for storage events, storageFn should switch on type of cleared, removed, added or updated.
@@ -1078,12 +3319,77 @@ func (t *Tab) SetJavaScriptPromptHandler(promptHandlerFn PromptHandlerFunc) {
 	})
 }
 
+// Answers every javascript dialog (alert, confirm, prompt and beforeunload) as it opens so they do not
+// block the page, accepting or dismissing it and entering promptText into prompts. If dialogFn is not nil
+// it is called with each dialog's message and type before the dialog is answered. Replaces any handler
+// set with SetJavaScriptPromptHandler.
+func (t *Tab) HandleJavaScriptDialogs(accept bool, promptText string, dialogFn PromptHandlerFunc) {
+	t.Subscribe("Page.javascriptDialogOpening", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.PageJavascriptDialogOpeningEvent{}
+		if err := json.Unmarshal(payload, message); err == nil && dialogFn != nil {
+			dialogFn(t, message.Params.Message, message.Params.Type)
+		}
+
+		if _, err := t.Page.HandleJavaScriptDialog(accept, promptText); err != nil {
+			t.debugf("unable to handle javascript dialog: %s\n", err)
+		}
+	})
+}
+
 // Allow the caller to be notified of DOM NodeChangeEvents. Simply call this with a nil function handler to stop
 // receiving dom event changes.
 func (t *Tab) GetDOMChanges(domHandlerFn DomChangeHandlerFunc) {
+	t.domChangeMutex.Lock()
+	defer t.domChangeMutex.Unlock()
+
 	t.domChangeHandler = domHandlerFn
 }
 
+// Returns a channel receiving DOM NodeChangeEvents as an alternative to a GetDOMChanges handler, calling
+// it again returns the same channel. The channel holds up to domChangeBufferSize events, events are
+// dropped rather than blocking the tab if the caller does not keep up. Call StopDOMChanges to close it.
+func (t *Tab) GetDOMChangesChannel() (<-chan *NodeChangeEvent, error) {
+	if t.IsShuttingDown() {
+		return nil, &InvalidTabErr{Message: "tab is shutting down"}
+	}
+
+	t.domChangeMutex.Lock()
+	defer t.domChangeMutex.Unlock()
+
+	if t.domChangeCh == nil {
+		t.domChangeCh = make(chan *NodeChangeEvent, domChangeBufferSize)
+	}
+	return t.domChangeCh, nil
+}
+
+// Stops sending DOM NodeChangeEvents to the handler and closes the channel returned by GetDOMChangesChannel.
+func (t *Tab) StopDOMChanges() {
+	t.domChangeMutex.Lock()
+	defer t.domChangeMutex.Unlock()
+
+	t.domChangeHandler = nil
+	if t.domChangeCh != nil {
+		close(t.domChangeCh)
+		t.domChangeCh = nil
+	}
+}
+
+// sends the change to the caller's channel if they requested one, dropping it if the channel is full.
+func (t *Tab) sendDOMChange(change *NodeChangeEvent) {
+	t.domChangeMutex.Lock()
+	defer t.domChangeMutex.Unlock()
+
+	if t.domChangeCh == nil {
+		return
+	}
+
+	select {
+	case t.domChangeCh <- change:
+	default:
+		t.debugf("dropping %s, DOM change channel is full\n", change.EventType)
+	}
+}
+
 // handles console messages coming in, responds by calling call back function
 func (t *Tab) defaultConsoleMessageAdded(fn ConsoleMessageFunc) GcdResponseFunc {
 	return func(target *gcd.ChromeTarget, payload []byte) {
@@ -1096,6 +3402,17 @@ func (t *Tab) defaultConsoleMessageAdded(fn ConsoleMessageFunc) GcdResponseFunc
 	}
 }
 
+// handles runtime exceptions coming in, responds by calling call back function
+func (t *Tab) defaultExceptionThrown(fn RuntimeExceptionFunc) GcdResponseFunc {
+	return func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.RuntimeExceptionThrownEvent{}
+		err := json.Unmarshal(payload, message)
+		if err == nil && message.Params.ExceptionDetails != nil {
+			fn(t, message.Params.ExceptionDetails)
+		}
+	}
+}
+
 // see tab_subscribers.go
 func (t *Tab) subscribeEvents() {
 	// DOM Related
@@ -1111,6 +3428,15 @@ func (t *Tab) subscribeEvents() {
 	// This doesn't seem useful.
 	// t.subscribeInlineStyleInvalidated()
 
+	// Frame related
+	t.subscribeExecutionContextCreated()
+	t.subscribeExecutionContextDestroyed()
+	t.subscribeExecutionContextsCleared()
+
+	// Script related
+	t.subscribeScriptParsed()
+	t.subscribeGlobalObjectCleared()
+
 	// Navigation Related
 	t.subscribeLoadEvent()
 	t.subscribeFrameLoadingEvent()
@@ -1131,9 +3457,13 @@ func (t *Tab) listenDebuggerEvents() {
 			t.debugf("%s\n", nodeChangeEvent.EventType)
 			t.handleNodeChange(nodeChangeEvent)
 			// if the caller registered a dom change listener, call it
-			if t.domChangeHandler != nil {
-				t.domChangeHandler(t, nodeChangeEvent)
+			t.domChangeMutex.Lock()
+			domChangeHandler := t.domChangeHandler
+			t.domChangeMutex.Unlock()
+			if domChangeHandler != nil {
+				domChangeHandler(t, nodeChangeEvent)
 			}
+			t.sendDOMChange(nodeChangeEvent)
 			t.lastNodeChangeTimeVal.Store(time.Now())
 		case reason := <-t.crashedCh:
 			if t.disconnectedHandler != nil {
@@ -1226,7 +3556,11 @@ func (t *Tab) handleDocumentUpdated() {
 	t.documentUpdated()
 	// notify if navigating that we received the document update event.
 	if t.IsNavigating() {
-		t.docUpdateCh <- struct{}{} // notify listeners document was updated
+		// don't block if the navigation timed out and is no longer waiting
+		select {
+		case t.docUpdateCh <- struct{}{}: // notify listeners document was updated
+		default:
+		}
 	}
 }
 
@@ -1252,8 +3586,6 @@ func (t *Tab) handleChildNodeInserted(parentNodeId int, node *gcdapi.DOMNode) {
 }
 
 // update ParentNodeId to remove child and iterate over Children recursively and invalidate them.
-// TODO: come up with a better way of removing children without direct access to the node
-// as it's a potential race condition if it's being modified.
 func (t *Tab) handleChildNodeRemoved(parentNodeId, nodeId int) {
 	t.debugf("child node removed: %d\n", nodeId)
 	ele, ok := t.getElement(nodeId)
@@ -1269,9 +3601,9 @@ func (t *Tab) handleChildNodeRemoved(parentNodeId, nodeId int) {
 		}
 	}
 
-	// if not ready, node will be nil
+	// if not ready, it has no children
 	if ele.IsReadyInvalid() {
-		t.invalidateChildren(ele.node)
+		t.invalidateChildren(ele)
 	}
 
 	t.eleMutex.Lock()
@@ -1279,40 +3611,27 @@ func (t *Tab) handleChildNodeRemoved(parentNodeId, nodeId int) {
 	t.eleMutex.Unlock()
 }
 
-// when a childNodeRemoved event occurs, we need to set each child
-// to invalidated and remove it from our elements map.
-func (t *Tab) invalidateChildren(node *gcdapi.DOMNode) {
-	// invalidate & remove ContentDocument node and children
-	if node.ContentDocument != nil {
-		ele, ok := t.getElement(node.ContentDocument.NodeId)
-		if ok {
-			t.invalidateRemove(ele)
-			t.invalidateChildren(node.ContentDocument)
-		}
-	}
-
-	if node.Children == nil {
-		return
-	}
-
-	// invalidate node.Children
-	for _, child := range node.Children {
-		ele, ok := t.getElement(child.NodeId)
+// when a childNodeRemoved event occurs, we need to set each child (and
+// ContentDocument) to invalidated and remove it from our elements map.
+func (t *Tab) invalidateChildren(ele *Element) {
+	for _, childId := range ele.ownedNodeIds() {
+		child, ok := t.getElement(childId)
 		if !ok {
 			continue
 		}
-		t.invalidateRemove(ele)
+		t.invalidateRemove(child)
 		// recurse and remove children of this node
-		t.invalidateChildren(ele.node)
+		t.invalidateChildren(child)
 	}
 }
 
 // Sets the element as invalid and removes it from our elements map
 func (t *Tab) invalidateRemove(ele *Element) {
-	t.debugf("invalidating nodeId: %d\n", ele.id)
+	nodeId := ele.NodeId()
+	t.debugf("invalidating nodeId: %d\n", nodeId)
 	ele.setInvalidated(true)
 	t.eleMutex.Lock()
-	delete(t.elements, ele.id)
+	delete(t.elements, nodeId)
 	t.eleMutex.Unlock()
 }
 
@@ -1332,15 +3651,33 @@ func (t *Tab) requestChildNodes(nodeId, depth int) {
 
 // Called if the element is known about but not yet populated. If it is not
 // known, we create a new element. If it is known we populate it and return it.
+// The lookup and insert happen under a single lock so concurrent callers for the
+// same nodeId always share one element.
 func (t *Tab) nodeToElement(node *gcdapi.DOMNode) *Element {
-	if ele, ok := t.getElement(node.NodeId); ok {
+	t.eleMutex.Lock()
+	defer t.eleMutex.Unlock()
+
+	if ele, ok := t.elements[node.NodeId]; ok {
 		ele.populateElement(node)
 		return ele
 	}
 	newEle := newReadyElement(t, node)
+	t.elements[node.NodeId] = newEle
 	return newEle
 }
 
+// Returns the DOMNode of nodeId as described by chrome. Described nodes (and their children) are not
+// assigned nodeIds, so we set the id and drop the children, which will arrive via setChildNodes.
+func (t *Tab) describeNode(nodeId int) (*gcdapi.DOMNode, error) {
+	node, err := t.DOM.DescribeNodeWithParams(&gcdapi.DOMDescribeNodeParams{NodeId: nodeId})
+	if err != nil {
+		return nil, err
+	}
+	node.NodeId = nodeId
+	node.Children = nil
+	return node, nil
+}
+
 // safely returns the element by looking it up by nodeId from our internal map.
 func (t *Tab) getElement(nodeId int) (*Element, bool) {
 	t.eleMutex.RLock()
@@ -1356,13 +3693,8 @@ func (t *Tab) getElement(nodeId int) (*Element, bool) {
 func (t *Tab) addNodes(node *gcdapi.DOMNode) {
 	t.debugf("addNode id: %d\n", node.NodeId)
 	t.debugf("%#v\n", node)
-	newEle := t.nodeToElement(node)
-
-	t.eleMutex.Lock()
-	t.elements[newEle.id] = newEle
-	t.eleMutex.Unlock()
-	//log.Printf("Added new element: %s\n", newEle)
-	t.requestChildNodes(newEle.id, 1)
+	t.nodeToElement(node)
+	t.requestChildNodes(node.NodeId, 1)
 	if node.Children != nil {
 		// add child nodes
 		for _, v := range node.Children {
@@ -1376,7 +3708,13 @@ func (t *Tab) addNodes(node *gcdapi.DOMNode) {
 }
 
 func (t *Tab) debugf(format string, args ...interface{}) {
-	if t.debug {
-		log.Printf(format, args...)
+	if !t.debug {
+		return
+	}
+
+	if t.logger != nil {
+		t.logger.Printf(format, args...)
+		return
 	}
+	log.Printf(format, args...)
 }
@@ -26,10 +26,53 @@ package autogcd
 
 import (
 	"encoding/json"
+	"time"
+
 	"github.com/wirepair/gcd"
 	"github.com/wirepair/gcd/gcdapi"
 )
 
+// every event a tab may subscribe to, including Target.targetCreated when it is the
+// AutoGcd new tab listener, so Close can remove them all.
+var tabEvents = []string{
+	"Console.messageAdded",
+	"DOM.attributeModified",
+	"DOM.attributeRemoved",
+	"DOM.characterDataModified",
+	"DOM.childNodeCountUpdated",
+	"DOM.childNodeInserted",
+	"DOM.childNodeRemoved",
+	"DOM.documentUpdated",
+	"DOM.inlineStyleInvalidatedEvent",
+	"DOM.setChildNodes",
+	"Debugger.globalObjectCleared",
+	"Debugger.paused",
+	"Debugger.scriptParsed",
+	"Inspector.detached",
+	"Inspector.targetCrashed",
+	"Network.loadingFailed",
+	"Network.loadingFinished",
+	"Network.requestIntercepted",
+	"Network.requestWillBeSent",
+	"Network.responseReceived",
+	"Page.frameStartedLoading",
+	"Page.frameStoppedLoading",
+	"Page.javascriptDialogOpening",
+	"Page.loadEventFired",
+	"PerformanceTimeline.timelineEventAdded",
+	"Runtime.consoleAPICalled",
+	"Runtime.exceptionThrown",
+	"Runtime.executionContextCreated",
+	"Runtime.executionContextDestroyed",
+	"Runtime.executionContextsCleared",
+	"Security.securityStateChanged",
+	"Storage.domStorageItemAdded",
+	"Storage.domStorageItemRemoved",
+	"Storage.domStorageItemUpdated",
+	"Storage.domStorageItemsCleared",
+	"Target.targetCreated",
+}
+
 func (t *Tab) subscribeTargetCrashed() {
 	t.Subscribe("Inspector.targetCrashed", func(target *gcd.ChromeTarget, payload []byte) {
 		select {
@@ -63,6 +106,7 @@ func (t *Tab) subscribeLoadEvent() {
 			select {
 			case t.navigationCh <- 0:
 			case <-t.exitCh:
+			default: // navigation already signaled or no longer waiting
 			}
 
 		}
@@ -95,6 +139,10 @@ func (t *Tab) subscribeFrameFinishedEvent() {
 		// has the top frame id begun navigating?
 		if err == nil && header.Params.FrameId == t.GetTopFrameId() {
 			t.setIsTransitioning(false)
+			select {
+			case t.transitionCh <- struct{}{}:
+			default: // already signaled
+			}
 		}
 	})
 }
@@ -175,6 +223,8 @@ func (t *Tab) subscribeChildNodeRemoved() {
 	})
 }
 
+// Sends the change to listenDebuggerEvents. Changes must not be dropped or our elements would no longer match
+// the DOM, so this waits for room in the buffered channel unless the tab is closed.
 func (t *Tab) dispatchNodeChange(evt *NodeChangeEvent) {
 	select {
 	case t.nodeChange <- evt:
@@ -198,9 +248,188 @@ func (t *Tab) subscribeInlineStyleInvalidated() {
 func (t *Tab) subscribeDocumentUpdated() {
 	// node ids are no longer valid
 	t.Subscribe("DOM.documentUpdated", func(target *gcd.ChromeTarget, payload []byte) {
-		select {
-		case t.nodeChange <- &NodeChangeEvent{EventType: DocumentUpdatedEvent}:
-		case <-t.exitCh:
+		t.dispatchNodeChange(&NodeChangeEvent{EventType: DocumentUpdatedEvent})
+	})
+}
+
+// tracks outstanding requests and calls the caller's network handlers if they are set.
+func (t *Tab) subscribeNetworkEvents() {
+	t.Subscribe("Network.requestWillBeSent", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.NetworkRequestWillBeSentEvent{}
+		if err := json.Unmarshal(payload, message); err != nil {
+			return
+		}
+		p := message.Params
+		request := &NetworkRequest{RequestId: p.RequestId, FrameId: p.FrameId, LoaderId: p.LoaderId, DocumentURL: p.DocumentURL, Request: p.Request, Timestamp: p.Timestamp, Initiator: p.Initiator, RedirectResponse: p.RedirectResponse, Type: p.Type}
+
+		t.networkMutex.Lock()
+		// loadingFinished/loadingFailed can beat requestWillBeSent, don't track a request that is already done
+		if _, finished := t.finishedRequests[p.RequestId]; !finished {
+			t.requests[p.RequestId] = request
+		}
+		requestHandlerFn := t.requestHandler
+		t.networkMutex.Unlock()
+
+		if requestHandlerFn != nil {
+			requestHandlerFn(t, request)
+		}
+	})
+
+	t.Subscribe("Network.responseReceived", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.NetworkResponseReceivedEvent{}
+		if err := json.Unmarshal(payload, message); err != nil {
+			return
+		}
+		t.networkMutex.RLock()
+		responseHandlerFn := t.responseHandler
+		t.networkMutex.RUnlock()
+
+		if responseHandlerFn != nil {
+			p := message.Params
+			response := &NetworkResponse{RequestId: p.RequestId, FrameId: p.FrameId, LoaderId: p.LoaderId, Response: p.Response, Timestamp: p.Timestamp, Type: p.Type}
+			responseHandlerFn(t, response)
+		}
+	})
+
+	t.Subscribe("Network.loadingFinished", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.NetworkLoadingFinishedEvent{}
+		if err := json.Unmarshal(payload, message); err != nil {
+			return
+		}
+		p := message.Params
+
+		t.networkMutex.Lock()
+		request, ok := t.requests[p.RequestId]
+		t.requestDone(p.RequestId)
+		finishedHandlerFn := t.finishedHandler
+		bodyPattern := t.bodyPattern
+		t.networkMutex.Unlock()
+
+		if ok && bodyPattern != nil && request.Request != nil && bodyPattern.MatchString(request.Request.Url) {
+			go t.collectResponseBody(p.RequestId, request.Request.Url)
+		}
+
+		if finishedHandlerFn != nil {
+			finishedHandlerFn(t, p.RequestId, p.EncodedDataLength, p.Timestamp)
+		}
+	})
+
+	t.Subscribe("Network.loadingFailed", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.NetworkLoadingFailedEvent{}
+		if err := json.Unmarshal(payload, message); err != nil {
+			return
+		}
+		t.networkMutex.Lock()
+		t.requestDone(message.Params.RequestId)
+		t.networkMutex.Unlock()
+	})
+}
+
+// stops tracking requestId and remembers it for finishedRequestTTL, expiring older ids.
+// Must be called with networkMutex held.
+func (t *Tab) requestDone(requestId string) {
+	delete(t.requests, requestId)
+	now := time.Now()
+	for id, finishedAt := range t.finishedRequests {
+		if now.Sub(finishedAt) > finishedRequestTTL {
+			delete(t.finishedRequests, id)
+		}
+	}
+	t.finishedRequests[requestId] = now
+}
+
+// maps each frame to its default execution context so we can evaluate script inside of it.
+func (t *Tab) subscribeExecutionContextCreated() {
+	t.Subscribe("Runtime.executionContextCreated", func(target *gcd.ChromeTarget, payload []byte) {
+		header := &gcdapi.RuntimeExecutionContextCreatedEvent{}
+		err := json.Unmarshal(payload, header)
+		if err != nil || header.Params.Context == nil {
+			return
+		}
+		context := header.Params.Context
+		frameId, _ := context.AuxData["frameId"].(string)
+		isDefault, _ := context.AuxData["isDefault"].(bool)
+		if frameId == "" || !isDefault {
+			return
+		}
+		t.contextMutex.Lock()
+		t.frameContexts[frameId] = context.Id
+		t.contextMutex.Unlock()
+	})
+}
+
+// removes the frame's execution context once it has been destroyed.
+func (t *Tab) subscribeExecutionContextDestroyed() {
+	t.Subscribe("Runtime.executionContextDestroyed", func(target *gcd.ChromeTarget, payload []byte) {
+		header := &gcdapi.RuntimeExecutionContextDestroyedEvent{}
+		if err := json.Unmarshal(payload, header); err != nil {
+			return
+		}
+		t.contextMutex.Lock()
+		for frameId, contextId := range t.frameContexts {
+			if contextId == header.Params.ExecutionContextId {
+				delete(t.frameContexts, frameId)
+			}
+		}
+		t.contextMutex.Unlock()
+	})
+}
+
+// all execution contexts are gone, usually due to a navigation.
+func (t *Tab) subscribeExecutionContextsCleared() {
+	t.Subscribe("Runtime.executionContextsCleared", func(target *gcd.ChromeTarget, payload []byte) {
+		t.contextMutex.Lock()
+		t.frameContexts = make(map[string]int)
+		t.contextMutex.Unlock()
+	})
+}
+
+// records the url of each script the debugger parses.
+func (t *Tab) subscribeScriptParsed() {
+	t.Subscribe("Debugger.scriptParsed", func(target *gcd.ChromeTarget, payload []byte) {
+		header := &gcdapi.DebuggerScriptParsedEvent{}
+		if err := json.Unmarshal(payload, header); err != nil {
+			return
+		}
+		t.scriptMutex.Lock()
+		if _, ok := t.scripts[header.Params.ScriptId]; !ok {
+			t.scriptOrder = append(t.scriptOrder, header.Params.ScriptId)
+		}
+		t.scripts[header.Params.ScriptId] = header.Params.Url
+		t.scriptMutex.Unlock()
+	})
+}
+
+// parsed scripts are no longer valid after the global object is cleared.
+func (t *Tab) subscribeGlobalObjectCleared() {
+	t.Subscribe("Debugger.globalObjectCleared", func(target *gcd.ChromeTarget, payload []byte) {
+		t.scriptMutex.Lock()
+		t.scripts = make(map[string]string)
+		t.scriptOrder = nil
+		t.scriptMutex.Unlock()
+	})
+}
+
+// requests (and their auth challenges) paused by request interception.
+func (t *Tab) subscribeRequestIntercepted() {
+	t.Subscribe("Network.requestIntercepted", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.NetworkRequestInterceptedEvent{}
+		if err := json.Unmarshal(payload, message); err != nil {
+			return
+		}
+		t.handleRequestIntercepted(message)
+	})
+}
+
+// records the latest security state of the page.
+func (t *Tab) subscribeSecurityStateChanged() {
+	t.Subscribe("Security.securityStateChanged", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.SecuritySecurityStateChangedEvent{}
+		if err := json.Unmarshal(payload, message); err != nil {
+			return
 		}
+		t.securityMutex.Lock()
+		t.securityState = message
+		t.securityMutex.Unlock()
 	})
 }
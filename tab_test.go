@@ -1,6 +1,14 @@
 package autogcd
 
 import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"sync"
 	"testing"
@@ -46,6 +54,101 @@ func TestTabGetCurrentUrl(t *testing.T) {
 	}
 }
 
+func TestTabGetDOMChangesChannel(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "button.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	changes, err := tab.GetDOMChangesChannel()
+	if err != nil {
+		t.Fatalf("error getting DOM changes channel: %s\n", err)
+	}
+
+	if _, err := tab.EvaluateScript("document.getElementById('button').setAttribute('data-changed', 'yes')"); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	timeout := time.After(testWaitTimeout)
+	for found := false; !found; {
+		select {
+		case change := <-changes:
+			found = change.EventType == AttributeModifiedEvent && change.Name == "data-changed"
+		case <-timeout:
+			t.Fatalf("timed out waiting for attribute modified change")
+		}
+	}
+
+	tab.StopDOMChanges()
+	for range changes {
+		// drain buffered changes until the channel is closed
+	}
+}
+
+func TestTabSetDebugLogger(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	buf := &bytes.Buffer{}
+	tab.SetDebugLogger(log.New(buf, "", 0))
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	tab.SetDebugLogger(nil)
+	if !strings.Contains(buf.String(), "navigating to "+testServerAddr+"index.html") {
+		t.Fatalf("expected navigation to be logged got: %s\n", buf.String())
+	}
+
+	buf.Reset()
+	if _, errorText, err := tab.Navigate(testServerAddr + "button.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output after disabling the logger got: %s\n", buf.String())
+	}
+}
+
+func TestTabGetURL(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if _, err := tab.EvaluateScript("history.pushState({}, '', 'pushed.html')"); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	url, err := tab.GetURL()
+	if err != nil {
+		t.Fatalf("error getting url: %s\n", err)
+	}
+
+	if url != testServerAddr+"pushed.html" {
+		t.Fatalf("expected pushed url got: %s\n", url)
+	}
+}
+
 func TestTabGetTitle(t *testing.T) {
 	testAuto := testDefaultStartup(t)
 
@@ -55,7 +158,7 @@ func TestTabGetTitle(t *testing.T) {
 	if err != nil {
 		t.Fatalf("error getting tab")
 	}
-	tab.DebugEvents(true)
+	tab.Debug(true)
 	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
 		t.Fatalf("Error navigating: %s %s\n", errorText, err)
 	}
@@ -101,6 +204,107 @@ func TestTabGetConsoleMessage(t *testing.T) {
 
 }
 
+func TestTabGetConsoleMessagesFiltered(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	timeout := time.NewTimer(5 * time.Second)
+	done := make(chan string, 10)
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	tab.GetConsoleMessagesFiltered([]string{"error"}, func(callerTab *Tab, message *gcdapi.ConsoleConsoleMessage) {
+		done <- message.Text
+	})
+
+	if _, err := tab.EvaluateScript("console.log('filtered out'); console.error('kept')"); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	select {
+	case text := <-done:
+		if text != "kept" {
+			t.Fatalf("expected only the error message got: %s\n", text)
+		}
+	case <-timeout.C:
+		t.Fatalf("error waiting for console message")
+	}
+}
+
+func TestTabOnConsole(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	timeout := time.NewTimer(5 * time.Second)
+	done := make(chan struct{})
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "console_log.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	tab.OnConsole(func(callerTab *Tab, level string, args []*gcdapi.RuntimeRemoteObject, stackTrace *gcdapi.RuntimeStackTrace) {
+		if level == "warning" && len(args) == 2 && args[0].Value == "object" && args[1].Type == "object" && stackTrace != nil {
+			callerTab.OnConsole(nil)
+			close(done)
+		}
+	})
+
+	if _, err := tab.EvaluateScript("console.warn('object', {a: 1})"); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	select {
+	case <-done:
+		return
+	case <-timeout.C:
+		t.Fatalf("error waiting for console api call")
+	}
+}
+
+func TestTabGetRuntimeExceptions(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	timeout := time.NewTimer(5 * time.Second)
+	done := make(chan struct{})
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	tab.GetRuntimeExceptions(func(callerTab *Tab, exception *gcdapi.RuntimeExceptionDetails) {
+		if strings.Contains(exceptionText(exception), "page error") {
+			callerTab.GetRuntimeExceptions(nil)
+			close(done)
+		}
+	})
+
+	if _, err := tab.EvaluateScript("setTimeout(function() { throw new Error('page error'); }, 0)"); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	select {
+	case <-done:
+		return
+	case <-timeout.C:
+		t.Fatalf("error waiting for runtime exception")
+	}
+}
+
 func TestTabGetPageSource(t *testing.T) {
 	//var src string
 	testAuto := testDefaultStartup(t)
@@ -202,8 +406,7 @@ func TestTabFrameGetPageSource(t *testing.T) {
 	//t.Logf("source: %s\n", src)
 }
 
-func TestTabGetFrameResources(t *testing.T) {
-	var resourceMap map[string]string
+func TestTabClose(t *testing.T) {
 	testAuto := testDefaultStartup(t)
 	defer testAuto.Shutdown()
 
@@ -212,65 +415,93 @@ func TestTabGetFrameResources(t *testing.T) {
 		t.Fatalf("error getting tab")
 	}
 
-	if _, errorText, err := tab.Navigate(testServerAddr + "iframe.html"); err != nil {
+	if _, errorText, err := tab.Navigate(testServerAddr + "button.html"); err != nil {
 		t.Fatalf("Error navigating: %s %s\n", errorText, err)
 	}
 
-	resourceMap, err = tab.GetFrameResources()
+	ele, err := tab.WaitForElementBySelector("button", testWaitTimeout)
 	if err != nil {
-		t.Fatalf("Error getting page source: %s\n", err)
+		t.Fatalf("error finding button: %s\n", err)
 	}
-	for k, v := range resourceMap {
-		//t.Logf("id: %s url: %s\n", k, v)
-		src, wasBase64, err := tab.GetFrameSource(k, v)
-		if err != nil {
-			t.Fatalf("error getting frame source: %s\n", err)
-		}
-		t.Logf("wasBase64: %v src: %s\n", wasBase64, src)
+
+	changes, err := tab.GetDOMChangesChannel()
+	if err != nil {
+		t.Fatalf("error getting dom changes channel: %s\n", err)
+	}
+
+	if err := tab.Close(); err != nil {
+		t.Fatalf("error closing tab: %s\n", err)
+	}
+
+	if !tab.IsShuttingDown() {
+		t.Fatalf("expected tab to be shutting down after close")
+	}
+
+	if !ele.IsInvalid() {
+		t.Fatalf("expected elements to be invalidated after close")
 	}
 
+	for range changes {
+	}
+
+	if err := tab.Close(); err != nil {
+		t.Fatalf("error closing tab twice: %s\n", err)
+	}
+
+	if err := testAuto.CloseTab(tab); err != nil {
+		t.Fatalf("error closing chrome tab after close: %s\n", err)
+	}
 }
 
-func TestTabPromptHandler(t *testing.T) {
+func TestTabGetElementBySelectorInFrame(t *testing.T) {
 	testAuto := testDefaultStartup(t)
 	defer testAuto.Shutdown()
 
-	timeout := time.NewTimer(5 * time.Second)
-	done := make(chan struct{})
-
 	tab, err := testAuto.NewTab()
 	if err != nil {
 		t.Fatalf("error getting tab")
 	}
 
-	promptHandlerFn := func(theTab *Tab, message, promptType string) {
-		// promptType is only set in Chrome and not chromium? Works in Windows Chrome, not in linux Chromium.
-		// if promptType == "prompt" {
-		theTab.Page.HandleJavaScriptDialog(true, "someinput")
-		//}
+	if _, errorText, err := tab.Navigate(testServerAddr + "iframe.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
 	}
-	tab.SetJavaScriptPromptHandler(promptHandlerFn)
-	msgHandler := func(callerTab *Tab, message *gcdapi.ConsoleConsoleMessage) {
-		if message.Text == "someinput" {
-			done <- struct{}{}
+
+	resources, err := tab.GetFrameResources()
+	if err != nil {
+		t.Fatalf("error getting frame resources: %s\n", err)
+	}
+
+	innerFrameId := ""
+	for frameId, url := range resources {
+		if strings.HasSuffix(url, "inner.html") {
+			innerFrameId = frameId
 		}
 	}
-	tab.GetConsoleMessages(msgHandler)
 
-	if _, errorText, err := tab.Navigate(testServerAddr + "prompt.html"); err != nil {
-		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	if innerFrameId == "" {
+		t.Fatalf("expected to find the inner frame got: %v\n", resources)
 	}
 
-	select {
-	case <-done:
-		return
-	case <-timeout.C:
-		t.Fatalf("error waiting for console message")
+	var output *Element
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		output, err = tab.GetElementBySelectorInFrame(innerFrameId, "#output")
+		return err == nil
+	})
+	if err != nil {
+		t.Fatalf("error getting element in frame: %s\n", err)
+	}
+
+	if err := output.WaitForReady(); err != nil {
+		t.Fatalf("error waiting for element in frame: %s\n", err)
+	}
+
+	if _, err := tab.GetElementBySelectorInFrame(innerFrameId, "#innerfr"); err == nil {
+		t.Fatalf("expected elements of the top document to not be found in the frame")
 	}
 }
 
-// prompts will block navigation from returning
-func TestTabNavigationTimeout(t *testing.T) {
+func TestTabGetFrameResources(t *testing.T) {
+	var resourceMap map[string]string
 	testAuto := testDefaultStartup(t)
 	defer testAuto.Shutdown()
 
@@ -278,70 +509,3065 @@ func TestTabNavigationTimeout(t *testing.T) {
 	if err != nil {
 		t.Fatalf("error getting tab")
 	}
-	tab.SetNavigationTimeout(10)
-	if _, errorText, err := tab.Navigate(testServerAddr + "prompt.html"); err == nil {
-		t.Fatalf("did not get an error navigating: %s %s\n", errorText, err)
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "iframe.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	resourceMap, err = tab.GetFrameResources()
+	if err != nil {
+		t.Fatalf("Error getting page source: %s\n", err)
+	}
+	for k, v := range resourceMap {
+		//t.Logf("id: %s url: %s\n", k, v)
+		src, wasBase64, err := tab.GetFrameSource(k, v)
+		if err != nil {
+			t.Fatalf("error getting frame source: %s\n", err)
+		}
+		t.Logf("wasBase64: %v src: %s\n", wasBase64, src)
 	}
+
 }
 
-func TestTabInjectScript(t *testing.T) {
+func TestTabGetAccessibleFrames(t *testing.T) {
 	testAuto := testDefaultStartup(t)
 	defer testAuto.Shutdown()
-	wg := &sync.WaitGroup{}
-	wg.Add(4) // should be called 2x, one for main page, one for script_inner.html
+
 	tab, err := testAuto.NewTab()
 	if err != nil {
 		t.Fatalf("error getting tab")
 	}
 
-	msgHandler := func(callerTab *Tab, message *gcdapi.ConsoleConsoleMessage) {
-		if strings.Contains(message.Text, "inject") {
-			//t.Logf("got message: %s\n", message.Text)
-			wg.Done()
-		}
-	}
-	tab.GetConsoleMessages(msgHandler)
-	tab.InjectScriptOnLoad("console.log('inject ' + location.href);")
-	tab.InjectScriptOnLoad("console.log('inject 2' + location.href);")
-	if _, errorText, err := tab.Navigate(testServerAddr + "script.html"); err != nil {
+	if _, errorText, err := tab.Navigate(testServerAddr + "iframe.html"); err != nil {
 		t.Fatalf("Error navigating: %s %s\n", errorText, err)
 	}
 
-	wg.Wait()
+	frames, err := tab.GetAccessibleFrames()
+	if err != nil {
+		t.Fatalf("error getting frames: %s\n", err)
+	}
+
+	if len(frames) != 2 {
+		t.Fatalf("expected 2 frames got: %v\n", frames)
+	}
+
+	if frames[0].FrameId != tab.GetTopFrameId() || !frames[0].Accessible {
+		t.Fatalf("expected accessible top frame first got: %#v\n", frames[0])
+	}
+
+	if frames[1].Url != testServerAddr+"inner.html" || !frames[1].Accessible {
+		t.Fatalf("expected same origin inner frame to be accessible got: %#v\n", frames[1])
+	}
 }
 
-func TestTabEvaluateScript(t *testing.T) {
+func TestTabEvaluateScriptOnFrame(t *testing.T) {
 	testAuto := testDefaultStartup(t)
 	defer testAuto.Shutdown()
-	wg := &sync.WaitGroup{}
-	wg.Add(1) // should be called 2x, one for main page, one for script_inner.html
+
 	tab, err := testAuto.NewTab()
 	if err != nil {
 		t.Fatalf("error getting tab")
 	}
 
-	msgHandler := func(callerTab *Tab, message *gcdapi.ConsoleConsoleMessage) {
-		if strings.Contains(message.Text, "inject") {
-			//t.Logf("got message: %s\n", message.Text)
-			wg.Done()
-		}
+	if _, errorText, err := tab.Navigate(testServerAddr + "iframe.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	frameId := testInnerFrameId(t, tab)
+	var title interface{}
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		rro, err := tab.EvaluateScriptOnFrame(frameId, "document.title")
+		if err != nil {
+			return false
+		}
+		title = rro.Value
+		return true
+	})
+	if err != nil {
+		t.Fatalf("error evaluating script on frame: %s\n", err)
+	}
+
+	if title != "inner frame document modification" {
+		t.Fatalf("expected inner frame title but got: %v\n", title)
+	}
+
+	if _, err := tab.EvaluateScriptOnFrame("notaframe", "document.title"); err == nil {
+		t.Fatalf("expected error evaluating script on unknown frame")
+	}
+}
+
+func TestTabGetExecutionContextForFrame(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "iframe.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	frameId := testInnerFrameId(t, tab)
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		_, err := tab.GetExecutionContextForFrame(frameId)
+		return err == nil
+	})
+	if err != nil {
+		t.Fatalf("timed out waiting for frame execution context: %s\n", err)
+	}
+
+	// navigating away destroys the inner frame and its context
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if _, err := tab.GetExecutionContextForFrame(frameId); err == nil {
+		t.Fatalf("execution context for removed frame was not cleared")
+	}
+}
+
+// returns the frameId of the first frame that is not the top frame.
+func TestTabPostMessageToFrame(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "iframe.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	frameId := testInnerFrameId(t, tab)
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		_, err := tab.EvaluateScriptOnFrame(frameId, "window.addEventListener('message', function(e) { window.received = e.data; });")
+		return err == nil
+	})
+	if err != nil {
+		t.Fatalf("error adding message listener to frame: %s\n", err)
+	}
+
+	if err := tab.PostMessageToFrame(frameId, "hello frame", "*"); err != nil {
+		t.Fatalf("error posting message to frame: %s\n", err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		rro, err := tab.EvaluateScriptOnFrame(frameId, "window.received")
+		return err == nil && rro.Value == "hello frame"
+	})
+	if err != nil {
+		t.Fatalf("frame did not receive posted message: %s\n", err)
+	}
+
+	if err := tab.PostMessageToFrame("notaframe", "hello", "*"); err == nil {
+		t.Fatalf("expected error posting message to unknown frame")
+	}
+}
+
+func TestTabSetFrameContent(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "iframe.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	frameId := testInnerFrameId(t, tab)
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		_, err := tab.GetExecutionContextForFrame(frameId)
+		return err == nil
+	})
+	if err != nil {
+		t.Fatalf("timed out waiting for frame execution context: %s\n", err)
+	}
+
+	if err := tab.SetFrameContent(frameId, "<html><body><div id='injected'>injected</div></body></html>"); err != nil {
+		t.Fatalf("error setting frame content: %s\n", err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		rro, err := tab.EvaluateScript("document.getElementById('innerfr').contentDocument.getElementById('injected').textContent")
+		return err == nil && rro.Value == "injected"
+	})
+	if err != nil {
+		t.Fatalf("parent did not observe injected frame content: %s\n", err)
+	}
+
+	if err := tab.SetFrameContent("notaframe", "<p>nope</p>"); err == nil {
+		t.Fatalf("expected error setting content of unknown frame")
+	}
+}
+
+func TestTabWaitForPostMessage(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "iframe.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	frameId := testInnerFrameId(t, tab)
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		tab.EvaluateScriptOnFrame(frameId, "window.parent.postMessage('ignored', '*'); window.parent.postMessage({ready: true}, '*');")
+	}()
+
+	data, err := tab.WaitForPostMessage(func(data string) bool {
+		return strings.Contains(data, "ready")
+	}, testWaitTimeout)
+	if err != nil {
+		t.Fatalf("error waiting for post message: %s\n", err)
+	}
+
+	if data != `{"ready":true}` {
+		t.Fatalf("unexpected post message data: %s\n", data)
+	}
+
+	if _, err := tab.WaitForPostMessage(func(data string) bool { return false }, 500*time.Millisecond); err == nil {
+		t.Fatalf("expected timeout waiting for post message")
+	}
+}
+
+func TestTabSetUserAgent(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if err := tab.SetUserAgent("autogcd-agent"); err != nil {
+		t.Fatalf("error setting user agent: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	rro, err := tab.EvaluateScript("navigator.userAgent")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "autogcd-agent" {
+		t.Fatalf("expected overridden user agent got: %v\n", rro.Value)
+	}
+
+	if err := tab.ClearUserAgent(); err != nil {
+		t.Fatalf("error clearing user agent: %s\n", err)
+	}
+
+	if err := tab.Reload(false, ""); err != nil {
+		t.Fatalf("error reloading: %s\n", err)
+	}
+
+	rro, err = tab.EvaluateScript("navigator.userAgent")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value == "autogcd-agent" || rro.Value == "" {
+		t.Fatalf("expected default user agent got: %v\n", rro.Value)
+	}
+}
+
+func TestTabSetGeolocation(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	origin := strings.TrimSuffix(testServerAddr, "/")
+	if err := overridenGrantPermissions(tab.ChromeTarget, origin, []string{"geolocation"}); err != nil {
+		t.Skipf("unable to grant geolocation permission: %s\n", err)
+	}
+
+	// a longitude of 0 must still be sent
+	if err := tab.SetGeolocation(51.5, 0, 10); err != nil {
+		t.Fatalf("error setting geolocation: %s\n", err)
+	}
+
+	rro, err := tab.EvaluatePromiseScript(`new Promise(function(resolve, reject) {
+	navigator.geolocation.getCurrentPosition(function(p) { resolve(p.coords.latitude + ',' + p.coords.longitude); }, function(e) { reject(e.message); });
+})`)
+	if err != nil {
+		t.Fatalf("error getting position: %s\n", err)
+	}
+
+	if rro.Value != "51.5,0" {
+		t.Fatalf("expected overridden position got: %v\n", rro.Value)
+	}
+
+	if err := tab.ClearGeolocation(); err != nil {
+		t.Fatalf("error clearing geolocation: %s\n", err)
+	}
+}
+
+func TestTabSetBackgroundColorOverride(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate("about:blank"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if err := tab.SetBackgroundColorOverride(0, 0, 0, 0); err != nil {
+		t.Fatalf("error setting background color override: %s\n", err)
+	}
+
+	testScreenShotAlpha(t, tab, 0)
+
+	if err := tab.ClearBackgroundColorOverride(); err != nil {
+		t.Fatalf("error clearing background color override: %s\n", err)
+	}
+
+	testScreenShotAlpha(t, tab, 0xffff)
+}
+
+func testScreenShotAlpha(t *testing.T, tab *Tab, expected uint32) {
+	imgBytes, err := tab.GetScreenShot()
+	if err != nil {
+		t.Fatalf("error getting screenshot: %s\n", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(imgBytes))
+	if err != nil {
+		t.Fatalf("error decoding screenshot: %s\n", err)
+	}
+
+	if _, _, _, a := img.At(0, 0).RGBA(); a != expected {
+		t.Fatalf("expected alpha %d got %d\n", expected, a)
+	}
+}
+
+func TestTabGetElementByIdFast(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "attributes.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	ele, err := tab.GetElementByIdFast("attr")
+	if err != nil {
+		t.Fatalf("error getting element: %s\n", err)
+	}
+
+	if !ele.IsReady() {
+		t.Fatalf("expected element to be ready")
+	}
+
+	if tagName, _ := ele.GetTagName(); tagName != "input" {
+		t.Fatalf("expected input element got: %s\n", tagName)
+	}
+
+	if _, err := tab.GetElementByIdFast("notanid"); err == nil {
+		t.Fatalf("expected error getting element that does not exist")
+	}
+}
+
+func TestTabGetElementByIdNested(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "nested.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	// elements which are not in the first branch of their parents
+	for id, tagName := range map[string]string{"second": "div", "third": "li", "deepest": "p"} {
+		ele, _, err := tab.GetElementById(id)
+		if err != nil {
+			t.Fatalf("error getting element %s: %s\n", id, err)
+		}
+
+		if err := ele.WaitForReady(); err != nil {
+			t.Fatalf("error waiting for element %s: %s\n", id, err)
+		}
+
+		if eleTagName, _ := ele.GetTagName(); eleTagName != tagName {
+			t.Fatalf("expected %s to be %s got: %s\n", id, tagName, eleTagName)
+		}
+
+		byNodeId, _ := tab.GetElementByNodeId(ele.NodeId())
+		if byNodeId != ele {
+			t.Fatalf("expected the same element by node id for %s\n", id)
+		}
+	}
+}
+
+func TestTabGetElementsBySelectorNested(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "nested.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	// matches which are more than one level below the document's children
+	for selector, count := range map[string]int{".item": 3, ".deep": 2} {
+		elements, err := tab.GetElementsBySelector(selector)
+		if err != nil {
+			t.Fatalf("error getting elements %s: %s\n", selector, err)
+		}
+
+		if len(elements) != count {
+			t.Fatalf("expected %d elements for %s got: %d\n", count, selector, len(elements))
+		}
+
+		for _, ele := range elements {
+			if ele == nil {
+				t.Fatalf("expected no nil elements for %s\n", selector)
+			}
+
+			if err := ele.WaitForReady(); err != nil {
+				t.Fatalf("error waiting for element %s: %s\n", selector, err)
+			}
+		}
+	}
+}
+
+func TestTabGetElementBySelector(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "nested.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	ele, err := tab.GetElementBySelector("#last .deep")
+	if err != nil {
+		t.Fatalf("error getting element by selector: %s\n", err)
+	}
+
+	if err := ele.WaitForReady(); err != nil {
+		t.Fatalf("error waiting for element: %s\n", err)
+	}
+
+	if id := ele.GetAttribute("id"); id != "deepest" {
+		t.Fatalf("expected deepest element got: %s\n", id)
+	}
+
+	if _, err := tab.GetElementBySelector(".missing"); err == nil {
+		t.Fatalf("expected error when no element matches")
+	} else if _, ok := err.(*ElementNotFoundErr); !ok {
+		t.Fatalf("expected ElementNotFoundErr got: %T %s\n", err, err)
+	}
+}
+
+func TestTabGetElementsByXPath(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "nested.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	elements, err := tab.GetElementsByXPath("//li[@class='item']")
+	if err != nil {
+		t.Fatalf("error getting elements by xpath: %s\n", err)
+	}
+
+	if len(elements) != 3 {
+		t.Fatalf("expected 3 elements got: %d\n", len(elements))
+	}
+
+	elements, err = tab.GetElementsByXPath("//p[contains(text(), 'deepest')]")
+	if err != nil {
+		t.Fatalf("error getting elements by xpath: %s\n", err)
+	}
+
+	if len(elements) != 1 {
+		t.Fatalf("expected 1 element got: %d\n", len(elements))
+	}
+
+	if err := elements[0].WaitForReady(); err != nil {
+		t.Fatalf("error waiting for element: %s\n", err)
+	}
+
+	if id := elements[0].GetAttribute("id"); id != "deepest" {
+		t.Fatalf("expected deepest element got: %s\n", id)
+	}
+
+	elements, err = tab.GetElementsByXPath("//table")
+	if err != nil {
+		t.Fatalf("error getting elements by xpath: %s\n", err)
+	}
+
+	if len(elements) != 0 {
+		t.Fatalf("expected no elements got: %d\n", len(elements))
+	}
+}
+
+func BenchmarkTabGetElementById(b *testing.B) {
+	tab, shutdown := testBenchmarkTab(b, "attributes.html")
+	defer shutdown()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ele, _, err := tab.GetElementById("attr")
+		if err != nil {
+			b.Fatalf("error getting element: %s\n", err)
+		}
+		if err := ele.WaitForReady(); err != nil {
+			b.Fatalf("error waiting for element: %s\n", err)
+		}
+	}
+}
+
+func BenchmarkTabGetElementByIdFast(b *testing.B) {
+	tab, shutdown := testBenchmarkTab(b, "attributes.html")
+	defer shutdown()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tab.GetElementByIdFast("attr"); err != nil {
+			b.Fatalf("error getting element: %s\n", err)
+		}
+	}
+}
+
+func TestTabGetElementByNodeId(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "attributes.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	nodeId, err := tab.DOM.QuerySelector(tab.GetTopNodeId(), "#attr")
+	if err != nil || nodeId == 0 {
+		t.Fatalf("error querying for element: %d %s\n", nodeId, err)
+	}
+
+	ele, ready := tab.GetElementByNodeId(nodeId)
+	if !ready {
+		t.Fatalf("expected element to be ready")
+	}
+
+	if ele.NodeId() != nodeId {
+		t.Fatalf("expected nodeId %d got %d\n", nodeId, ele.NodeId())
+	}
+
+	if value := ele.GetAttribute("x"); value != "y" {
+		t.Fatalf("expected attribute x=y got: %s\n", value)
+	}
+
+	if _, ready := tab.GetElementByNodeId(999999); ready {
+		t.Fatalf("expected unknown nodeId to not be ready")
+	}
+}
+
+func TestTabGetElementByNodeIdCached(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "attributes.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	first, err := tab.GetElementBySelector("#attr")
+	if err != nil {
+		t.Fatalf("error finding element: %s\n", err)
+	}
+
+	second, err := tab.GetElementBySelector("#attr")
+	if err != nil {
+		t.Fatalf("error finding element again: %s\n", err)
+	}
+
+	if first != second {
+		t.Fatalf("expected the same element to be returned for the same node")
+	}
+
+	if cached, ready := tab.GetElementByNodeId(first.NodeId()); !ready || cached != first {
+		t.Fatalf("expected GetElementByNodeId to return the cached element")
+	}
+
+	if _, ok := tab.GetAllElements()[first.NodeId()]; !ok {
+		t.Fatalf("expected element to be in the elements map")
+	}
+}
+
+// run with -race to detect unsynchronized access to the tab's elements.
+func TestTabGetElementByNodeIdConcurrent(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "nested.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	// continuously insert, modify and remove nodes so DOM events fire while we look up elements.
+	script := `window.churn = setInterval(function() {
+	var d = document.createElement('div');
+	d.className = 'churn';
+	d.innerHTML = '<span>a</span><span>b</span>';
+	document.body.appendChild(d);
+	d.setAttribute('data-x', Date.now());
+	var old = document.querySelectorAll('.churn');
+	if (old.length > 5) { old[0].remove(); }
+}, 5);`
+	if _, err := tab.EvaluateScript(script); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	done := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				nodeIds, err := tab.DOM.QuerySelectorAll(tab.GetTopNodeId(), "*")
+				if err != nil {
+					continue
+				}
+				for _, nodeId := range nodeIds {
+					if ele, ready := tab.GetElementByNodeId(nodeId); ready {
+						ele.GetAttribute("class")
+					}
+				}
+				for _, ele := range tab.GetAllElements() {
+					ele.IsReady()
+				}
+			}
+		}()
+	}
+
+	time.Sleep(2 * time.Second)
+	close(done)
+	wg.Wait()
+
+	if _, err := tab.EvaluateScript("clearInterval(window.churn);"); err != nil {
+		t.Fatalf("error stopping script: %s\n", err)
+	}
+
+	if _, err := tab.GetElementBySelector("#deepest"); err != nil {
+		t.Fatalf("error finding element after concurrent access: %s\n", err)
+	}
+}
+
+func BenchmarkTabGetElementByNodeId(b *testing.B) {
+	tab, shutdown := testBenchmarkTab(b, "big_body.html")
+	defer shutdown()
+
+	nodeIds, err := tab.DOM.QuerySelectorAll(tab.GetTopNodeId(), "*")
+	if err != nil {
+		b.Fatalf("error querying for elements: %s\n", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tab.GetElementByNodeId(nodeIds[i%len(nodeIds)])
+	}
+}
+
+func testBenchmarkTab(b *testing.B, page string) (*Tab, func()) {
+	testAuto := testDefaultStartup(b)
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		testAuto.Shutdown()
+		b.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + page); err != nil {
+		testAuto.Shutdown()
+		b.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+	tab.WaitStable()
+	return tab, func() { testAuto.Shutdown() }
+}
+
+func TestTabSetAcceptLanguage(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if err := tab.SetAcceptLanguage("fr-FR,fr;q=0.9"); err != nil {
+		t.Fatalf("error setting accept language: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	rro, err := tab.EvaluateScript("navigator.language + ',' + navigator.languages.join(',')")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "fr-FR,fr-FR,fr" {
+		t.Fatalf("expected french languages got: %v\n", rro.Value)
+	}
+
+	if err := tab.SetAcceptLanguage("de"); err != nil {
+		t.Fatalf("error replacing accept language: %s\n", err)
+	}
+
+	rro, err = tab.EvaluateScript("navigator.language")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "de" {
+		t.Fatalf("expected replaced language got: %v\n", rro.Value)
+	}
+}
+
+func TestTabGetFrameOwnerElement(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "iframe.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	ele, err := tab.GetFrameOwnerElement(testInnerFrameId(t, tab))
+	if err != nil {
+		t.Fatalf("error getting frame owner: %s\n", err)
+	}
+
+	if err := ele.WaitForReady(); err != nil {
+		t.Fatalf("error waiting for frame owner: %s\n", err)
+	}
+
+	if id := ele.GetAttribute("id"); id != "innerfr" {
+		t.Fatalf("expected innerfr owner got: %s\n", id)
+	}
+
+	if _, err := tab.GetFrameOwnerElement(tab.GetTopFrameId()); err == nil {
+		t.Fatalf("expected error getting owner of top frame")
+	}
+}
+
+func TestTabWaitForStableDOM(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	script := "var count = 0; var interval = setInterval(function() { document.body.appendChild(document.createElement('div')); if (++count === 20) { clearInterval(interval); } }, 50);"
+	if _, err := tab.EvaluateScript(script); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	start := time.Now()
+	if err := tab.WaitForStableDOM(300*time.Millisecond, testWaitTimeout); err != nil {
+		t.Fatalf("error waiting for stable DOM: %s\n", err)
+	}
+
+	if time.Since(start) < time.Second {
+		t.Fatalf("DOM reported stable while it was still changing")
+	}
+
+	if _, err := tab.EvaluateScript("setInterval(function() { document.body.appendChild(document.createElement('div')); }, 50);"); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if err := tab.WaitForStableDOM(300*time.Millisecond, time.Second); err == nil {
+		t.Fatalf("expected timeout waiting for constantly changing DOM")
+	}
+}
+
+func TestTabSetProxyAuth(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if err := tab.SetProxyAuth("user", "pass"); err != nil {
+		t.Fatalf("error setting proxy auth: %s\n", err)
+	}
+
+	// intercepted requests without a proxy challenge must still be continued
+	if _, errorText, err := tab.Navigate(testServerAddr + "debugger.html"); err != nil {
+		t.Fatalf("Error navigating with interception enabled: %s %s\n", errorText, err)
+	}
+
+	if _, err := tab.GetScriptSourceByUrl("debugger.js"); err != nil {
+		t.Fatalf("expected intercepted script to load: %s\n", err)
+	}
+
+	if err := tab.SetProxyAuth("", ""); err != nil {
+		t.Fatalf("error clearing proxy auth: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating after interception disabled: %s %s\n", errorText, err)
+	}
+}
+
+func TestTabInjectStyle(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	styleId, err := tab.InjectStyle("body { visibility: hidden !important; }")
+	if err != nil {
+		t.Fatalf("error injecting style: %s\n", err)
+	}
+
+	rro, err := tab.EvaluateScript("getComputedStyle(document.body).visibility")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "hidden" {
+		t.Fatalf("expected injected style to apply got: %v\n", rro.Value)
+	}
+
+	if err := tab.RemoveInjectedStyle(styleId); err != nil {
+		t.Fatalf("error removing injected style: %s\n", err)
+	}
+
+	rro, err = tab.EvaluateScript("getComputedStyle(document.body).visibility")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "visible" {
+		t.Fatalf("expected injected style to be removed got: %v\n", rro.Value)
+	}
+
+	if err := tab.RemoveInjectedStyle(styleId); err == nil {
+		t.Fatalf("expected error removing style twice")
+	}
+}
+
+func TestTabInjectjQuery(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+	tab.SetJQueryUrl(testServerAddr + "jquery.js")
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if err := tab.InjectjQuery(); err != nil {
+		t.Fatalf("error injecting jQuery: %s\n", err)
+	}
+
+	if _, err := tab.EvaluateScript("window.jQuery.marker = 'existing';"); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	// an existing jQuery must not be replaced
+	if err := tab.InjectjQuery(); err != nil {
+		t.Fatalf("error injecting jQuery again: %s\n", err)
+	}
+
+	rro, err := tab.EvaluateScript("window.jQuery.marker + ',' + $('body').length")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "existing,1" {
+		t.Fatalf("expected existing jQuery to be kept got: %v\n", rro.Value)
+	}
+
+	if _, err := tab.EvaluateScript("delete window.jQuery; delete window.$;"); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	tab.SetJQueryUrl(testServerAddr + "nonexistent.js")
+	if err := tab.InjectjQuery(); err == nil {
+		t.Fatalf("expected error injecting jQuery from missing url\n")
+	}
+}
+
+func TestTabClickAt(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "click.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if err := tab.ClickAt(50, 50, ClickOptions{Button: "middle", Ctrl: true, Shift: true}); err != nil {
+		t.Fatalf("error clicking: %s\n", err)
+	}
+
+	rro, err := tab.EvaluateScript("window.lastClick")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "1,1,false,true,false,true" {
+		t.Fatalf("expected middle ctrl+shift click got: %v\n", rro.Value)
+	}
+
+	if err := tab.Click(50, 50); err != nil {
+		t.Fatalf("error clicking: %s\n", err)
+	}
+
+	rro, err = tab.EvaluateScript("window.lastClick")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "0,1,false,false,false,false" {
+		t.Fatalf("expected plain left click got: %v\n", rro.Value)
+	}
+}
+
+func TestTabGetMetaTags(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "meta.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	metaTags, err := tab.GetMetaTags()
+	if err != nil {
+		t.Fatalf("error getting meta tags: %s\n", err)
+	}
+
+	expected := map[string]string{
+		"description":  "a page with meta tags",
+		"og:title":     "Meta Title",
+		"og:image":     "http://localhost/image.png",
+		"twitter:card": "summary",
+	}
+
+	if len(metaTags) != len(expected) {
+		t.Fatalf("expected %d meta tags got: %v\n", len(expected), metaTags)
+	}
+
+	for name, content := range expected {
+		if metaTags[name] != content {
+			t.Fatalf("expected %s to be %s got: %s\n", name, content, metaTags[name])
+		}
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "nested.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	metaTags, err = tab.GetMetaTags()
+	if err != nil {
+		t.Fatalf("error getting meta tags: %s\n", err)
+	}
+
+	if metaTags == nil || len(metaTags) != 0 {
+		t.Fatalf("expected no meta tags got: %v\n", metaTags)
+	}
+}
+
+func TestTabGetLinks(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "links.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	links, err := tab.GetLinks(false)
+	if err != nil {
+		t.Fatalf("error getting links: %s\n", err)
+	}
+
+	expected := []string{testServerAddr + "button.html", testServerAddr + "index.html", "http://example.com/page"}
+	if strings.Join(links, " ") != strings.Join(expected, " ") {
+		t.Fatalf("expected links %v got: %v\n", expected, links)
+	}
+
+	links, err = tab.GetLinks(true)
+	if err != nil {
+		t.Fatalf("error getting same origin links: %s\n", err)
+	}
+
+	if strings.Join(links, " ") != strings.Join(expected[:2], " ") {
+		t.Fatalf("expected same origin links %v got: %v\n", expected[:2], links)
+	}
+}
+
+func TestTabGetImages(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "images.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	images, err := tab.GetImages()
+	if err != nil {
+		t.Fatalf("error getting images: %s\n", err)
+	}
+
+	if len(images) != 2 {
+		t.Fatalf("expected 2 images got: %v\n", images)
+	}
+
+	if !images[0].HasAlt || images[0].Alt != "three by two" || images[0].NaturalWidth != 3 || images[0].NaturalHeight != 2 {
+		t.Fatalf("unexpected first image: %#v\n", images[0])
+	}
+
+	if images[1].HasAlt || images[1].Src != testServerAddr+"missing.png" || images[1].NaturalWidth != 0 {
+		t.Fatalf("unexpected second image: %#v\n", images[1])
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "links.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	images, err = tab.GetImages()
+	if err != nil {
+		t.Fatalf("error getting images: %s\n", err)
+	}
+
+	if images == nil || len(images) != 0 {
+		t.Fatalf("expected no images got: %v\n", images)
+	}
+}
+
+func TestTabGetVisibleText(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "click.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	text, err := tab.GetVisibleText()
+	if err != nil {
+		t.Fatalf("error getting visible text: %s\n", err)
+	}
+
+	if strings.TrimSpace(text) != "click area" {
+		t.Fatalf("expected visible text only got: %s\n", text)
+	}
+}
+
+func TestTabScrollWheel(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "scroll.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if err := tab.ScrollWheel(100, 100, 0, 500); err != nil {
+		t.Fatalf("error scrolling: %s\n", err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		rro, err := tab.EvaluateScript("window.scrollY")
+		if err != nil {
+			return false
+		}
+		scrollY, ok := rro.Value.(float64)
+		return ok && scrollY > 0
+	})
+	if err != nil {
+		t.Fatalf("expected page to scroll down: %s\n", err)
+	}
+}
+
+func TestTabClickAll(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "clickall.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	err = tab.ClickAll(".expand")
+	aggregateErr, ok := err.(*AggregateErr)
+	if !ok || len(aggregateErr.Errors) != 1 {
+		t.Fatalf("expected a single error for the hidden element got: %v\n", err)
+	}
+
+	if !strings.Contains(aggregateErr.Errors[0].Error(), "not visible") {
+		t.Fatalf("expected the hidden element to be skipped got: %s\n", aggregateErr.Errors[0])
+	}
+
+	rro, err := tab.EvaluateScript("window.clicked.join(',')")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "one,two,three" {
+		t.Fatalf("expected visible elements to be clicked in order got: %v\n", rro.Value)
+	}
+
+	if err := tab.ClickAll(".missing"); err == nil {
+		t.Fatalf("expected error when no elements match")
+	} else if _, ok := err.(*ElementNotFoundErr); !ok {
+		t.Fatalf("expected ElementNotFoundErr got: %T %s\n", err, err)
+	}
+}
+
+func TestTabFillForm(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "form.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	fields := []FormField{
+		{Selector: "#username", Value: "autogcd"},
+		{Selector: "#password", Value: "secret"},
+		{Selector: "#comment", Value: "hello"},
+	}
+
+	if err := tab.FillForm(fields, ""); err != nil {
+		t.Fatalf("error filling form: %s\n", err)
+	}
+
+	rro, err := tab.EvaluateScript("[username.value, password.value, comment.value].join(',')")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "autogcd,secret,hello" {
+		t.Fatalf("expected form to be filled got: %v\n", rro.Value)
+	}
+
+	err = tab.FillForm([]FormField{{Selector: "#username", Value: "again"}, {Selector: "#missing", Value: "value"}}, "#login")
+	aggregateErr, ok := err.(*AggregateErr)
+	if !ok || len(aggregateErr.Errors) != 1 {
+		t.Fatalf("expected a single field error got: %v\n", err)
+	}
+
+	rro, err = tab.EvaluateScript("username.value")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "again" {
+		t.Fatalf("expected remaining fields to be filled got: %v\n", rro.Value)
+	}
+
+	if url, err := tab.GetURL(); err != nil || strings.Contains(url, "username=") {
+		t.Fatalf("expected form to not be submitted when a field fails got: %s %v\n", url, err)
+	}
+
+	if err := tab.FillForm(fields, "#login"); err != nil {
+		t.Fatalf("error filling and submitting form: %s\n", err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		url, err := tab.GetURL()
+		return err == nil && strings.Contains(url, "username=autogcd") && strings.Contains(url, "comment=hello")
+	})
+	if err != nil {
+		t.Fatalf("expected form to be submitted after all fields were filled: %s\n", err)
+	}
+}
+
+func TestTabEvaluateWithUserGesture(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "form.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	// copying is only allowed with a user gesture
+	rro, err := tab.EvaluateWithUserGesture("username.select(); document.execCommand('copy')")
+	if err != nil {
+		t.Fatalf("error evaluating with user gesture: %s\n", err)
+	}
+
+	if rro.Value != true {
+		t.Fatalf("expected copy to be allowed got: %v\n", rro.Value)
+	}
+
+	if _, err := tab.EvaluateWithUserGesture("throw new Error('fail')"); err == nil {
+		t.Fatalf("expected exception to be returned as an error")
+	}
+}
+
+func TestTabReadClipboard(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "form.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if _, err := tab.EvaluateWithUserGesture("username.select(); document.execCommand('copy')"); err != nil {
+		t.Fatalf("error copying: %s\n", err)
+	}
+
+	text, err := tab.ReadClipboard()
+	if err != nil {
+		t.Fatalf("error reading clipboard: %s\n", err)
+	}
+
+	if text != "prefilled" {
+		t.Fatalf("expected copied text got: %s\n", text)
+	}
+}
+
+func TestTabSetRequestInterception(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	interceptedMutex := &sync.Mutex{}
+	intercepted := make([]string, 0)
+	interceptFn := func(callerTab *Tab, event *gcdapi.NetworkRequestInterceptedEvent) {
+		interceptedMutex.Lock()
+		intercepted = append(intercepted, event.Params.ResourceType)
+		interceptedMutex.Unlock()
+		callerTab.ContinueInterceptedRequest(event.Params.InterceptionId)
+	}
+
+	if err := tab.SetRequestInterception(interceptFn, &InterceptPattern{ResourceType: "Script"}); err != nil {
+		t.Fatalf("error setting request interception: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "debugger.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if err := tab.SetRequestInterception(nil); err != nil {
+		t.Fatalf("error stopping request interception: %s\n", err)
+	}
+
+	interceptedMutex.Lock()
+	defer interceptedMutex.Unlock()
+
+	if len(intercepted) != 1 || intercepted[0] != "Script" {
+		t.Fatalf("expected only the script to be intercepted got: %v\n", intercepted)
+	}
+}
+
+func TestTabAbortInterceptedRequest(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	interceptFn := func(callerTab *Tab, event *gcdapi.NetworkRequestInterceptedEvent) {
+		if event.Params.ResourceType == "Script" {
+			callerTab.AbortInterceptedRequest(event.Params.InterceptionId, "")
+			return
+		}
+		callerTab.ContinueInterceptedRequest(event.Params.InterceptionId)
+	}
+
+	if err := tab.SetRequestInterception(interceptFn); err != nil {
+		t.Fatalf("error setting request interception: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "debugger.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if err := tab.SetRequestInterception(nil); err != nil {
+		t.Fatalf("error stopping request interception: %s\n", err)
+	}
+
+	if scriptIds := tab.GetScriptIdsByUrl("debugger.js"); len(scriptIds) != 0 {
+		t.Fatalf("expected aborted script to not be parsed got: %v\n", scriptIds)
+	}
+}
+
+func TestTabSetNetworkConditions(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if err := tab.SetOffline(); err != nil {
+		t.Fatalf("error going offline: %s\n", err)
+	}
+
+	if _, err := tab.FetchInPage(testServerAddr+"index.html", "GET", nil, ""); err == nil {
+		t.Fatalf("expected fetch to fail while offline")
+	}
+
+	if err := tab.SetNetworkConditions(false, 500, -1, -1); err != nil {
+		t.Fatalf("error setting network conditions: %s\n", err)
+	}
+
+	start := time.Now()
+	if _, err := tab.FetchInPage(testServerAddr+"index.html?latency", "GET", nil, ""); err != nil {
+		t.Fatalf("error fetching with latency: %s\n", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Fatalf("expected fetch to take at least the emulated latency got: %s\n", elapsed)
+	}
+
+	if err := tab.ClearNetworkConditions(); err != nil {
+		t.Fatalf("error clearing network conditions: %s\n", err)
+	}
+
+	if _, err := tab.FetchInPage(testServerAddr+"index.html", "GET", nil, ""); err != nil {
+		t.Fatalf("error fetching after clearing network conditions: %s\n", err)
+	}
+}
+
+func TestTabBlockURLs(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if err := tab.BlockURLs([]string{"*debugger.js"}); err != nil {
+		t.Fatalf("error blocking urls: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "debugger.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if scriptIds := tab.GetScriptIdsByUrl("debugger.js"); len(scriptIds) != 0 {
+		t.Fatalf("expected blocked script to not be parsed got: %v\n", scriptIds)
+	}
+
+	if err := tab.BlockURLs(nil); err != nil {
+		t.Fatalf("error unblocking urls: %s\n", err)
+	}
+
+	if err := tab.Reload(true, ""); err != nil {
+		t.Fatalf("error reloading: %s\n", err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		return len(tab.GetScriptIdsByUrl("debugger.js")) > 0
+	})
+	if err != nil {
+		t.Fatalf("expected script to load once unblocked: %s\n", err)
+	}
+}
+
+func TestTabSetReducedMotion(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if err := tab.SetReducedMotion(true); err != nil {
+		t.Fatalf("error setting reduced motion: %s\n", err)
+	}
+
+	rro, err := tab.EvaluateScript("matchMedia('(prefers-reduced-motion: reduce)').matches")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != true {
+		t.Fatalf("expected reduced motion to be emulated")
+	}
+
+	if err := tab.SetReducedMotion(false); err != nil {
+		t.Fatalf("error clearing reduced motion: %s\n", err)
+	}
+
+	rro, err = tab.EvaluateScript("matchMedia('(prefers-reduced-motion: no-preference)').matches")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != true {
+		t.Fatalf("expected no motion preference to be emulated")
+	}
+}
+
+func TestTabGetSecurityState(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	state, err := tab.GetSecurityState()
+	if err != nil {
+		t.Fatalf("error getting security state: %s\n", err)
+	}
+
+	// localhost over http is not considered secure
+	if state == "secure" || state == "" {
+		t.Fatalf("unexpected security state for http page: %s\n", state)
+	}
+}
+
+func TestTabGetCertificateInfo(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if _, err := tab.GetCertificateInfo(); err == nil {
+		t.Fatalf("expected error getting certificate of http page")
+	}
+}
+
+func TestCertInfoFromExplanations(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	cert := server.Certificate()
+	explanations := []*gcdapi.SecuritySecurityStateExplanation{
+		{SecurityState: "neutral"},
+		{SecurityState: "secure", Certificate: []string{base64.StdEncoding.EncodeToString(cert.Raw)}},
+	}
+
+	info, err := certInfoFromExplanations(explanations)
+	if err != nil {
+		t.Fatalf("error parsing certificate: %s\n", err)
+	}
+
+	if info.Subject != cert.Subject.String() || info.Issuer != cert.Issuer.String() {
+		t.Fatalf("unexpected subject or issuer: %s %s\n", info.Subject, info.Issuer)
+	}
+
+	if !info.ValidFrom.Equal(cert.NotBefore) || !info.ValidTo.Equal(cert.NotAfter) {
+		t.Fatalf("unexpected validity: %s %s\n", info.ValidFrom, info.ValidTo)
+	}
+
+	if _, err := certInfoFromExplanations(explanations[:1]); err == nil {
+		t.Fatalf("expected error when no certificate is present")
+	}
+}
+
+func TestTabWaitForPageReady(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body><script>fetch('/slow').then(function(r) { return r.text(); }).then(function(text) { var d = document.createElement('div'); d.id = 'loaded'; document.body.appendChild(d); });</script></body></html>"))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("done"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if err := tab.GetNetworkTraffic(nil, nil, nil); err != nil {
+		t.Fatalf("Error listening to network traffic: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(server.URL); err != nil {
+		close(release)
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	err = tab.WaitForPageReadyWithOptions(&PageReadyOptions{NetworkIdleTime: 200 * time.Millisecond}, time.Second)
+	if err == nil {
+		close(release)
+		t.Fatalf("expected page to not be ready while a request is pending")
+	}
+
+	if _, ok := err.(*TimeoutErr); !ok || !strings.Contains(err.Error(), "network was not idle") {
+		close(release)
+		t.Fatalf("expected network timeout error got: %T %s\n", err, err)
+	}
+	close(release)
+
+	if err := tab.WaitForPageReady(testWaitTimeout); err != nil {
+		t.Fatalf("error waiting for page ready: %s\n", err)
+	}
+
+	if _, _, err := tab.GetElementById("loaded"); err != nil {
+		t.Fatalf("expected element added after request to exist: %s\n", err)
+	}
+}
+
+func TestTabWaitForElementBySelector(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	script := "setTimeout(function() { var d = document.createElement('div'); d.id = 'late'; document.body.appendChild(d); }, 500);"
+	if _, err := tab.EvaluateScript(script); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	ele, err := tab.WaitForElementBySelector("#late", testWaitTimeout)
+	if err != nil {
+		t.Fatalf("error waiting for element: %s\n", err)
+	}
+
+	if err := ele.WaitForReady(); err != nil {
+		t.Fatalf("error waiting for element to be ready: %s\n", err)
+	}
+
+	if id := ele.GetAttribute("id"); id != "late" {
+		t.Fatalf("expected element with id late got: %s\n", id)
+	}
+
+	if _, err := tab.WaitForElementBySelector("#never", 500*time.Millisecond); err == nil {
+		t.Fatalf("expected timeout waiting for element that is never added\n")
+	} else if _, ok := err.(*TimeoutErr); !ok {
+		t.Fatalf("expected TimeoutErr got: %T %s\n", err, err)
+	}
+}
+
+func TestTabWaitForElementVisible(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "box.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if _, err := tab.WaitForElementVisible("#hidden", 500*time.Millisecond); err == nil {
+		t.Fatalf("expected timeout waiting for hidden element\n")
+	} else if _, ok := err.(*TimeoutErr); !ok {
+		t.Fatalf("expected TimeoutErr got: %T %s\n", err, err)
+	}
+
+	script := "setTimeout(function() { document.getElementById('hidden').style.display = 'block'; }, 500);"
+	if _, err := tab.EvaluateScript(script); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	ele, err := tab.WaitForElementVisible("#hidden", testWaitTimeout)
+	if err != nil {
+		t.Fatalf("error waiting for element to be visible: %s\n", err)
+	}
+
+	visible, err := ele.IsVisible()
+	if err != nil {
+		t.Fatalf("error checking visibility: %s\n", err)
+	}
+
+	if !visible {
+		t.Fatalf("expected element to be visible\n")
+	}
+}
+
+func TestTabWaitForElementText(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "click.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if _, err := tab.EvaluateScript("setTimeout(function() { clickarea.innerText = 'Saved!'; }, 500);"); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if err := tab.WaitForElementText("#clickarea", "Saved", testWaitTimeout); err != nil {
+		t.Fatalf("error waiting for element text: %s\n", err)
+	}
+
+	err = tab.WaitForElementText("#clickarea", "Failed", 500*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "Saved!") {
+		t.Fatalf("expected timeout error with last observed text got: %v\n", err)
+	}
+}
+
+func TestTabScreenshotElements(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "form.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, ElementsBySelectorNotEmpty(tab, "input"))
+	if err != nil {
+		t.Fatalf("error finding inputs, timed out waiting: %s\n", err)
+	}
+
+	screenShots, err := tab.ScreenshotElements("input")
+	if err != nil {
+		t.Fatalf("error taking element screenshots: %s\n", err)
+	}
+
+	if len(screenShots) != 2 {
+		t.Fatalf("expected 2 screenshots got %d\n", len(screenShots))
+	}
+
+	for nodeId, imgBytes := range screenShots {
+		img, err := png.Decode(bytes.NewReader(imgBytes))
+		if err != nil {
+			t.Fatalf("error decoding screenshot of %d: %s\n", nodeId, err)
+		}
+
+		fullPage, err := tab.GetScreenShot()
+		if err != nil {
+			t.Fatalf("error taking screenshot: %s\n", err)
+		}
+
+		page, err := png.Decode(bytes.NewReader(fullPage))
+		if err != nil {
+			t.Fatalf("error decoding page screenshot: %s\n", err)
+		}
+
+		if img.Bounds().Dx() >= page.Bounds().Dx() {
+			t.Fatalf("expected element screenshot to be clipped")
+		}
+	}
+}
+
+func TestTabGetFullPageScreenShot(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "big_body.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	before, err := tab.EvaluateScript("window.innerWidth + 'x' + window.innerHeight")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	viewportBytes, err := tab.GetScreenShot()
+	if err != nil {
+		t.Fatalf("error taking screenshot: %s\n", err)
+	}
+
+	fullPageBytes, err := tab.GetFullPageScreenShot()
+	if err != nil {
+		t.Fatalf("error taking full page screenshot: %s\n", err)
+	}
+
+	viewport, err := png.Decode(bytes.NewReader(viewportBytes))
+	if err != nil {
+		t.Fatalf("error decoding screenshot: %s\n", err)
+	}
+
+	fullPage, err := png.Decode(bytes.NewReader(fullPageBytes))
+	if err != nil {
+		t.Fatalf("error decoding full page screenshot: %s\n", err)
+	}
+
+	if fullPage.Bounds().Dy() <= viewport.Bounds().Dy() {
+		t.Fatalf("expected full page screenshot to be taller than the viewport: %d <= %d\n", fullPage.Bounds().Dy(), viewport.Bounds().Dy())
+	}
+
+	after, err := tab.EvaluateScript("window.innerWidth + 'x' + window.innerHeight")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if before.Value != after.Value {
+		t.Fatalf("expected viewport to be restored to %v got %v\n", before.Value, after.Value)
+	}
+}
+
+func TestTabCaptureFrames(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	script := "var shade = 0; setInterval(function() { shade = (shade + 40) % 256; document.body.style.background = 'rgb(' + shade + ',0,0)'; }, 50);"
+	if _, err := tab.EvaluateScript(script); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	frames, err := tab.CaptureFrames(3, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("error capturing frames: %s\n", err)
+	}
+
+	if len(frames) != 3 {
+		t.Fatalf("expected 3 frames got %d\n", len(frames))
+	}
+
+	for i, frame := range frames {
+		if _, err := png.Decode(bytes.NewReader(frame)); err != nil {
+			t.Fatalf("error decoding frame %d: %s\n", i, err)
+		}
+	}
+
+	if bytes.Equal(frames[0], frames[1]) && bytes.Equal(frames[1], frames[2]) {
+		t.Fatalf("expected the animation to change between frames")
+	}
+
+	if frames, err := tab.CaptureFrames(2, 0); err != nil || len(frames) != 2 {
+		t.Fatalf("expected 2 back to back frames got %d: %v\n", len(frames), err)
+	}
+
+	if frames, err := tab.CaptureFrames(-1, time.Second); err != nil || len(frames) != 0 {
+		t.Fatalf("expected no frames for a negative count got %d: %v\n", len(frames), err)
+	}
+}
+
+func TestTabPrintToPDF(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	pdf, err := tab.PrintToPDF(PDFOptions{Landscape: true, PrintBackground: true})
+	if err != nil && strings.Contains(err.Error(), "not implemented") {
+		t.Skip("printing to pdf requires headless chrome")
+	}
+
+	if err != nil {
+		t.Fatalf("error printing to pdf: %s\n", err)
+	}
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF")) {
+		t.Fatalf("expected pdf data")
+	}
+}
+
+func TestTabCollectPerformanceTimeline(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "text.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	var lock sync.Mutex
+	lcpEvents := 0
+	err = tab.CollectPerformanceTimeline([]string{"largest-contentful-paint"}, func(tab *Tab, event *TimelineEvent) {
+		lock.Lock()
+		if event.Type == "largest-contentful-paint" && event.LcpDetails != nil {
+			lcpEvents++
+		}
+		lock.Unlock()
+	})
+	if err != nil && strings.Contains(err.Error(), "wasn't found") {
+		t.Skip("performance timeline requires a newer chrome")
+	}
+
+	if err != nil {
+		t.Fatalf("error collecting performance timeline: %s\n", err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return lcpEvents > 0
+	})
+	if err != nil {
+		t.Fatalf("expected a buffered largest-contentful-paint event: %s\n", err)
+	}
+
+	if err := tab.StopPerformanceTimeline(); err != nil {
+		t.Fatalf("error stopping performance timeline: %s\n", err)
+	}
+}
+
+func TestPDFOptionsParams(t *testing.T) {
+	params := (&PDFOptions{}).params()
+	if params["marginTop"] != float64(0) || params["marginLeft"] != float64(0) {
+		t.Fatalf("expected zero margins to be sent got: %v\n", params)
+	}
+
+	if _, ok := params["paperWidth"]; ok {
+		t.Fatalf("expected default paper width to be left to chrome")
+	}
+
+	params = (&PDFOptions{PaperWidth: 8.27, PaperHeight: 11.69, Scale: 0.5}).params()
+	if params["paperWidth"] != 8.27 || params["paperHeight"] != 11.69 || params["scale"] != 0.5 {
+		t.Fatalf("expected paper size and scale got: %v\n", params)
+	}
+}
+
+func TestTabSetPageScaleFactor(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if err := tab.SetPageScaleFactor(2); err != nil {
+		t.Fatalf("error setting page scale factor: %s\n", err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		rro, err := tab.EvaluateScript("window.visualViewport.scale")
+		return err == nil && rro.Value == float64(2)
+	})
+	if err != nil {
+		t.Fatalf("expected page scale factor of 2: %s\n", err)
+	}
+}
+
+func TestTabTap(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if err := tab.SetTouchEmulation(true); err != nil {
+		t.Fatalf("error enabling touch emulation: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "touch.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if err := tab.Tap(50, 50); err != nil {
+		t.Fatalf("error tapping: %s\n", err)
+	}
+
+	rro, err := tab.EvaluateScript("window.touchEvents.join(',')")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "touchstart,touchend" {
+		t.Fatalf("expected touch events got: %v\n", rro.Value)
+	}
+
+	if err := tab.SetTouchEmulation(false); err != nil {
+		t.Fatalf("error disabling touch emulation: %s\n", err)
+	}
+}
+
+func TestTabSetDeviceMetrics(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if err := tab.SetDeviceMetrics(375, 667, 2, false); err != nil {
+		t.Fatalf("error setting device metrics: %s\n", err)
+	}
+
+	rro, err := tab.EvaluateScript("[window.innerWidth, window.innerHeight, window.devicePixelRatio, matchMedia('(max-width: 400px)').matches].join(',')")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "375,667,2,true" {
+		t.Fatalf("expected emulated metrics got: %v\n", rro.Value)
+	}
+
+	if err := tab.ClearDeviceMetrics(); err != nil {
+		t.Fatalf("error clearing device metrics: %s\n", err)
+	}
+
+	rro, err = tab.EvaluateScript("window.innerWidth")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if width, ok := rro.Value.(float64); !ok || width == 375 {
+		t.Fatalf("expected the window's width after clearing got: %v\n", rro.Value)
+	}
+}
+
+func TestTabEmulateDevice(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if err := tab.EmulateDevice("not a device"); err == nil {
+		t.Fatalf("expected error emulating unknown device\n")
+	} else if _, ok := err.(*UnknownDeviceErr); !ok {
+		t.Fatalf("expected UnknownDeviceErr got: %T %s\n", err, err)
+	}
+
+	if err := tab.EmulateDevice("iPhone 12"); err != nil {
+		t.Fatalf("error emulating device: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	rro, err := tab.EvaluateScript("[window.innerWidth, window.devicePixelRatio, 'ontouchstart' in window, navigator.userAgent].join(',')")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	device := DevicePresets["iPhone 12"]
+	expected := fmt.Sprintf("%d,%v,true,%s", device.Width, device.DeviceScaleFactor, device.UserAgent)
+	if rro.Value != expected {
+		t.Fatalf("expected %s got %v\n", expected, rro.Value)
+	}
+}
+
+func TestTabResetEmulation(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	state := "[window.innerWidth, window.visualViewport.scale, matchMedia('(prefers-reduced-motion: reduce)').matches, navigator.userAgent, navigator.onLine].join(',')"
+	rro, err := tab.EvaluateScript(state)
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+	original := rro.Value
+
+	if err := tab.EmulateDevice("iPhone 12"); err != nil {
+		t.Fatalf("error emulating device: %s\n", err)
+	}
+
+	if err := tab.SetReducedMotion(true); err != nil {
+		t.Fatalf("error setting reduced motion: %s\n", err)
+	}
+
+	if err := tab.SetPageScaleFactor(2); err != nil {
+		t.Fatalf("error setting page scale factor: %s\n", err)
+	}
+
+	if err := tab.SetOffline(); err != nil {
+		t.Fatalf("error setting offline: %s\n", err)
+	}
+
+	if err := tab.ResetEmulation(); err != nil {
+		t.Fatalf("error resetting emulation: %s\n", err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		rro, err := tab.EvaluateScript(state)
+		return err == nil && rro.Value == original
+	})
+	if err != nil {
+		t.Fatalf("expected emulation to be reset to %v: %s\n", original, err)
+	}
+}
+
+func testInnerFrameId(t *testing.T, tab *Tab) string {
+	resourceMap, err := tab.GetFrameResources()
+	if err != nil {
+		t.Fatalf("Error getting frame resources: %s\n", err)
+	}
+	for frameId := range resourceMap {
+		if frameId != tab.GetTopFrameId() {
+			return frameId
+		}
+	}
+	t.Fatalf("unable to find inner frame")
+	return ""
+}
+
+func TestTabHandleJavaScriptDialogs(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	var lock sync.Mutex
+	dialogs := make([]string, 0)
+	tab.HandleJavaScriptDialogs(true, "autogcd", func(tab *Tab, message, promptType string) {
+		lock.Lock()
+		dialogs = append(dialogs, promptType+":"+message)
+		lock.Unlock()
+	})
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	rro, err := tab.EvaluateScript("window.alert('hello'); window.confirm('sure?') + ',' + window.prompt('name?', '')")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "true,autogcd" {
+		t.Fatalf("expected dialogs to be accepted got: %v\n", rro.Value)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if strings.Join(dialogs, ",") != "alert:hello,confirm:sure?,prompt:name?" {
+		t.Fatalf("expected each dialog to be passed to the handler got: %v\n", dialogs)
+	}
+}
+
+func TestTabPromptHandler(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	timeout := time.NewTimer(5 * time.Second)
+	done := make(chan struct{})
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	promptHandlerFn := func(theTab *Tab, message, promptType string) {
+		// promptType is only set in Chrome and not chromium? Works in Windows Chrome, not in linux Chromium.
+		// if promptType == "prompt" {
+		theTab.Page.HandleJavaScriptDialog(true, "someinput")
+		//}
+	}
+	tab.SetJavaScriptPromptHandler(promptHandlerFn)
+	msgHandler := func(callerTab *Tab, message *gcdapi.ConsoleConsoleMessage) {
+		if message.Text == "someinput" {
+			done <- struct{}{}
+		}
+	}
+	tab.GetConsoleMessages(msgHandler)
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "prompt.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	select {
+	case <-done:
+		return
+	case <-timeout.C:
+		t.Fatalf("error waiting for console message")
+	}
+}
+
+// prompts will block navigation from returning
+func TestTabNavigationTimeout(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+	tab.SetNavigationTimeout(10)
+	if _, errorText, err := tab.Navigate(testServerAddr + "prompt.html"); err == nil {
+		t.Fatalf("did not get an error navigating: %s %s\n", errorText, err)
+	}
+}
+
+func TestTabSetContent(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if err := tab.SetContent("<html><head><title>snippet</title></head><body><button id=\"snippet\">snippet</button></body></html>"); err != nil {
+		t.Fatalf("error setting content: %s\n", err)
+	}
+
+	title, err := tab.GetTitle()
+	if err != nil {
+		t.Fatalf("error getting title: %s\n", err)
+	}
+
+	if title != "snippet" {
+		t.Fatalf("expected snippet title got: %s\n", title)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		_, _, err := tab.GetElementById("snippet")
+		return err == nil
+	})
+	if err != nil {
+		t.Fatalf("expected to find snippet button: %s\n", err)
+	}
+}
+
+func TestTabNavigateWithTimeout(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	_, _, err = tab.NavigateWithTimeout(testServerAddr+"big_body.html", time.Millisecond)
+	if err == nil {
+		t.Fatalf("did not get an error navigating with a 1ms timeout\n")
+	}
+
+	if _, ok := err.(*TimeoutErr); !ok {
+		t.Fatalf("expected TimeoutErr got: %T %s\n", err, err)
+	}
+
+	// signals from the timed out navigation must not complete the next one early
+	if _, errorText, err := tab.NavigateWithTimeout(testServerAddr+"button.html", 10*time.Second); err != nil {
+		t.Fatalf("Error navigating after timeout: %s %s\n", errorText, err)
+	}
+
+	if _, _, err := tab.GetElementById("button"); err != nil {
+		t.Fatalf("error finding button after navigation: %s\n", err)
+	}
+}
+
+func TestTabReload(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "button.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if _, err := tab.EvaluateScript("window.beforeReload = true;"); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if err := tab.Reload(false, ""); err != nil {
+		t.Fatalf("error reloading: %s\n", err)
+	}
+
+	rro, err := tab.EvaluateScript("typeof window.beforeReload")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != "undefined" {
+		t.Fatalf("expected a new document after reload got: %v\n", rro.Value)
+	}
+
+	if _, _, err := tab.GetElementById("button"); err != nil {
+		t.Fatalf("error finding button after reload: %s\n", err)
+	}
+}
+
+func TestTabWaitForNavigation(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "links.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	noop := func() error { return nil }
+	if err := tab.WaitForNavigation(noop, 500*time.Millisecond); err == nil {
+		t.Fatalf("expected timeout waiting for navigation when none occurs")
+	}
+
+	links, err := tab.GetElementsBySelector("a")
+	if err != nil || len(links) == 0 {
+		t.Fatalf("error getting links: %s\n", err)
+	}
+
+	if err := tab.WaitForNavigation(links[0].Click, testWaitTimeout); err != nil {
+		t.Fatalf("error waiting for navigation: %s\n", err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		_, _, err := tab.GetElementById("button")
+		return err == nil
+	})
+	if err != nil {
+		t.Fatalf("expected button.html to be loaded after navigation: %s\n", err)
+	}
+}
+
+func TestTabBackForward(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "button.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "input.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if err := tab.Forward(); err == nil {
+		t.Fatalf("expected error navigating forward from the latest entry")
+	}
+
+	if err := tab.Back(); err != nil {
+		t.Fatalf("error navigating back: %s\n", err)
+	}
+
+	if url, _ := tab.GetCurrentUrl(); url != testServerAddr+"button.html" {
+		t.Fatalf("expected to be back on button.html got: %s\n", url)
+	}
+
+	if _, _, err := tab.GetElementById("button"); err != nil {
+		t.Fatalf("error finding button after navigating back: %s\n", err)
+	}
+
+	if err := tab.Forward(); err != nil {
+		t.Fatalf("error navigating forward: %s\n", err)
+	}
+
+	if url, _ := tab.GetCurrentUrl(); url != testServerAddr+"input.html" {
+		t.Fatalf("expected to be forward on input.html got: %s\n", url)
+	}
+}
+
+func TestTabBackForwardSameDocument(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "button.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if _, err := tab.EvaluateScript("location.hash = 'section'; history.pushState({}, '', 'pushed.html'); 1"); err != nil {
+		t.Fatalf("error changing history: %s\n", err)
+	}
+
+	tab.SetNavigationTimeout(5 * time.Second)
+
+	if err := tab.Back(); err != nil {
+		t.Fatalf("error navigating back to the hash entry: %s\n", err)
+	}
+
+	if url, _ := tab.GetURL(); url != testServerAddr+"button.html#section" {
+		t.Fatalf("expected to be back on the hash entry got: %s\n", url)
+	}
+
+	if err := tab.Back(); err != nil {
+		t.Fatalf("error navigating back from the hash entry: %s\n", err)
+	}
+
+	if url, _ := tab.GetURL(); url != testServerAddr+"button.html" {
+		t.Fatalf("expected to be back on button.html got: %s\n", url)
+	}
+
+	if err := tab.Forward(); err != nil {
+		t.Fatalf("error navigating forward to the hash entry: %s\n", err)
+	}
+
+	if err := tab.Forward(); err != nil {
+		t.Fatalf("error navigating forward to the pushed entry: %s\n", err)
+	}
+
+	if url, _ := tab.GetURL(); url != testServerAddr+"pushed.html" {
+		t.Fatalf("expected to be forward on the pushed entry got: %s\n", url)
+	}
+}
+
+func TestTabStopLoading(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	navigateErr := make(chan error, 1)
+	go func() {
+		_, _, err := tab.NavigateWithTimeout(server.URL, 30*time.Second)
+		navigateErr <- err
+	}()
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		return tab.IsNavigating()
+	})
+	if err != nil {
+		t.Fatalf("expected tab to be navigating: %s\n", err)
+	}
+
+	if err := tab.StopLoading(); err != nil {
+		t.Fatalf("error stopping loading: %s\n", err)
+	}
+
+	select {
+	case err := <-navigateErr:
+		if err == nil {
+			t.Fatalf("expected error from stopped navigation")
+		}
+	case <-time.After(testWaitTimeout):
+		t.Fatalf("navigation did not return after stopping loading")
+	}
+}
+
+func TestTabInjectScript(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+	wg := &sync.WaitGroup{}
+	wg.Add(4) // should be called 2x, one for main page, one for script_inner.html
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	msgHandler := func(callerTab *Tab, message *gcdapi.ConsoleConsoleMessage) {
+		if strings.Contains(message.Text, "inject") {
+			//t.Logf("got message: %s\n", message.Text)
+			wg.Done()
+		}
+	}
+	tab.GetConsoleMessages(msgHandler)
+	tab.InjectScriptOnLoad("console.log('inject ' + location.href);")
+	tab.InjectScriptOnLoad("console.log('inject 2' + location.href);")
+	if _, errorText, err := tab.Navigate(testServerAddr + "script.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	wg.Wait()
+}
+
+func TestTabEvaluateScript(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+	wg := &sync.WaitGroup{}
+	wg.Add(1) // should be called 2x, one for main page, one for script_inner.html
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	msgHandler := func(callerTab *Tab, message *gcdapi.ConsoleConsoleMessage) {
+		if strings.Contains(message.Text, "inject") {
+			//t.Logf("got message: %s\n", message.Text)
+			wg.Done()
+		}
 	}
 	tab.GetConsoleMessages(msgHandler)
 
-	if _, errorText, err := tab.Navigate(testServerAddr + "button.html"); err != nil {
+	if _, errorText, err := tab.Navigate(testServerAddr + "button.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+	_, errEval := tab.EvaluateScript("JSON.stringify(document)")
+	if errEval != nil {
+		t.Fatalf("error evaluating script: %s\n", errEval)
+	}
+	// trigger completion
+	_, errEval = tab.EvaluateScript("console.log('inject ' + location.href);")
+	if errEval != nil {
+		t.Fatalf("error evaluating trigger script: %s\n", errEval)
+	}
+	wg.Wait()
+	//t.Logf("res: %#v\n", res)
+}
+
+func TestTabGetResponseBody(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body><script>fetch('/data.json');</script></body></html>"))
+	})
+	mux.HandleFunc("/data.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"autogcd"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	var lock sync.Mutex
+	dataRequestId := ""
+	finished := make(map[string]bool)
+	requestHandlerFn := func(callerTab *Tab, request *NetworkRequest) {
+		lock.Lock()
+		if strings.HasSuffix(request.Request.Url, "/data.json") {
+			dataRequestId = request.RequestId
+		}
+		lock.Unlock()
+	}
+	finishedHandlerFn := func(callerTab *Tab, requestId string, dataLength, timeStamp float64) {
+		lock.Lock()
+		finished[requestId] = true
+		lock.Unlock()
+	}
+
+	if err := tab.GetNetworkTraffic(requestHandlerFn, nil, finishedHandlerFn); err != nil {
+		t.Fatalf("Error listening to network traffic: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(server.URL); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return dataRequestId != "" && finished[dataRequestId]
+	})
+	if err != nil {
+		t.Fatalf("timed out waiting for data.json to finish: %s\n", err)
+	}
+
+	lock.Lock()
+	requestId := dataRequestId
+	lock.Unlock()
+
+	body, base64Encoded, err := tab.GetResponseBody(requestId)
+	if err != nil {
+		t.Fatalf("error getting response body: %s\n", err)
+	}
+
+	if base64Encoded || body != `{"name":"autogcd"}` {
+		t.Fatalf("expected json body got: %s (base64 %t)\n", body, base64Encoded)
+	}
+}
+
+func TestTabFetchInPage(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+		w.Write([]byte("<html><body>logged in</body></html>"))
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(cookie.Value + "|" + r.Header.Get("X-Test") + "|" + string(data)))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(server.URL); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	result, err := tab.FetchInPage(server.URL+"/api", "POST", map[string]string{"X-Test": "header"}, "body")
+	if err != nil {
+		t.Fatalf("error fetching in page: %s\n", err)
+	}
+
+	if result.Status != http.StatusCreated {
+		t.Fatalf("expected status %d got: %d\n", http.StatusCreated, result.Status)
+	}
+
+	if result.Headers["x-method"] != "POST" {
+		t.Fatalf("expected x-method header to be POST got: %#v\n", result.Headers)
+	}
+
+	if result.Body != "abc|header|body" {
+		t.Fatalf("expected session cookie, header and body to be sent got: %s\n", result.Body)
+	}
+
+	if _, err := tab.FetchInPage("http://localhost:1/", "GET", nil, ""); err == nil {
+		t.Fatalf("expected error fetching from a closed port")
+	} else if _, ok := err.(*ScriptEvaluationErr); !ok {
+		t.Fatalf("expected ScriptEvaluationErr got: %T %s\n", err, err)
+	}
+}
+
+func TestTabEvaluateScriptException(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	rro, err := tab.EvaluateScript("1 + 2")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != float64(3) {
+		t.Fatalf("expected 3 got: %v\n", rro.Value)
+	}
+
+	_, err = tab.EvaluateScript("throw new Error('evaluate failure')")
+	if err == nil {
+		t.Fatalf("expected error evaluating script which throws\n")
+	}
+
+	evalErr, ok := err.(*ScriptEvaluationErr)
+	if !ok {
+		t.Fatalf("expected ScriptEvaluationErr got: %T %s\n", err, err)
+	}
+
+	if !strings.Contains(evalErr.Error(), "evaluate failure") || evalErr.ExceptionDetails == nil {
+		t.Fatalf("expected exception message and details got: %s\n", evalErr)
+	}
+
+	if _, err := tab.EvaluateScript("notAFunction()"); err == nil {
+		t.Fatalf("expected error calling undefined function\n")
+	}
+}
+
+func TestTabEvaluateScriptWithTimeout(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	rro, err := tab.EvaluateScriptWithTimeout("1 + 2", testWaitTimeout)
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if rro.Value != float64(3) {
+		t.Fatalf("expected 3 got: %v\n", rro.Value)
+	}
+
+	_, err = tab.EvaluateScriptWithTimeout("while (true) {}", 500*time.Millisecond)
+	if err == nil {
+		t.Fatalf("expected timeout evaluating infinite loop\n")
+	}
+
+	if _, ok := err.(*TimeoutErr); !ok {
+		t.Fatalf("expected TimeoutErr got: %T %s\n", err, err)
+	}
+
+	// the page must still respond once the loop is terminated
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		rro, err := tab.EvaluateScriptWithTimeout("'alive'", time.Second)
+		return err == nil && rro.Value == "alive"
+	})
+	if err != nil {
+		t.Fatalf("page did not respond after terminating script: %s\n", err)
+	}
+}
+
+func TestTabSetScriptSource(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "debugger.html"); err != nil {
 		t.Fatalf("Error navigating: %s %s\n", errorText, err)
 	}
-	_, errEval := tab.EvaluateScript("JSON.stringify(document)")
-	if errEval != nil {
-		t.Fatalf("error evaluating script: %s\n", errEval)
+
+	scriptIds := tab.GetScriptIdsByUrl("debugger.js")
+	if len(scriptIds) != 1 {
+		t.Fatalf("expected 1 scriptId for debugger.js got: %d\n", len(scriptIds))
 	}
-	// trigger completion
-	_, errEval = tab.EvaluateScript("console.log('inject ' + location.href);")
-	if errEval != nil {
-		t.Fatalf("error evaluating trigger script: %s\n", errEval)
+
+	if err := tab.SetScriptSource(scriptIds[0], "function getValue() {\n\treturn 2;\n}\n"); err != nil {
+		t.Fatalf("error setting script source: %s\n", err)
 	}
-	wg.Wait()
-	//t.Logf("res: %#v\n", res)
+
+	rro, err := tab.EvaluateScript("getValue()")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if value, ok := rro.Value.(float64); !ok || value != 2 {
+		t.Fatalf("expected live edited value of 2 got: %v\n", rro.Value)
+	}
+}
+
+func TestTabGetScriptSourceByUrl(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "debugger.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	src, err := tab.GetScriptSourceByUrl("debugger.js")
+	if err != nil {
+		t.Fatalf("error getting script source: %s\n", err)
+	}
+
+	if !strings.Contains(src, "function getValue()") {
+		t.Fatalf("unexpected script source: %s\n", src)
+	}
+
+	// a second matching script, the most recently parsed is returned.
+	if _, err := tab.EvaluateScript("var later = 1;\n//# sourceURL=later-debugger.js"); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		return len(tab.GetScriptIdsByUrl("debugger.js")) == 2
+	})
+	if err != nil {
+		t.Fatalf("expected two scripts matching debugger.js: %s\n", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		src, err = tab.GetScriptSourceByUrl("debugger.js")
+		if err != nil {
+			t.Fatalf("error getting script source: %s\n", err)
+		}
+
+		if !strings.Contains(src, "var later") {
+			t.Fatalf("expected the most recently parsed script got: %s\n", src)
+		}
+	}
+
+	if _, err := tab.GetScriptSourceByUrl("notascript.js"); err == nil {
+		t.Fatalf("expected error getting source of unknown script")
+	}
+}
+
+func TestTabBreakpoint(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	timeout := time.NewTimer(5 * time.Second)
+	paused := make(chan string, 1)
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	tab.OnPaused(func(callerTab *Tab, event *gcdapi.DebuggerPausedEvent) {
+		paused <- event.Params.Reason
+		if err := callerTab.Resume(); err != nil {
+			t.Logf("error resuming: %s\n", err)
+		}
+	})
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "debugger.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	breakpointId, err := tab.SetBreakpoint("debugger\\.js", 1)
+	if err != nil {
+		t.Fatalf("error setting breakpoint: %s\n", err)
+	}
+
+	go tab.EvaluateScript("getValue()")
+
+	select {
+	case <-paused:
+	case <-timeout.C:
+		t.Fatalf("timed out waiting for breakpoint to pause execution")
+	}
+
+	if err := tab.RemoveBreakpoint(breakpointId); err != nil {
+		t.Fatalf("error removing breakpoint: %s\n", err)
+	}
+	tab.OnPaused(nil)
+}
+
+func TestTabProfiling(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "debugger.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if err := tab.StartProfiling(); err != nil {
+		t.Fatalf("error starting profiler: %s\n", err)
+	}
+
+	if _, err := tab.EvaluateScript("for (var i = 0; i < 100000; i++) { getValue(); }"); err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	profile, err := tab.StopProfiling()
+	if err != nil {
+		t.Fatalf("error stopping profiler: %s\n", err)
+	}
+
+	if len(profile.Nodes) == 0 {
+		t.Fatalf("expected profile to contain nodes")
+	}
+
+	if profile.EndTime < profile.StartTime {
+		t.Fatalf("expected end time %f to be after start time %f\n", profile.EndTime, profile.StartTime)
+	}
+}
+
+func TestTabSetCookieViaJS(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "httponly", Value: "server", HttpOnly: true})
+		w.Write([]byte("<html><body>httponly</body></html>"))
+	}))
+	defer server.Close()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(server.URL); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if err := tab.SetCookieViaJS("jscookie", "fromjs"); err != nil {
+		t.Fatalf("error setting cookie: %s\n", err)
+	}
+
+	cookies, err := tab.GetCookies()
+	if err != nil {
+		t.Fatalf("Error getting cookies: %s\n", err)
+	}
+
+	found := false
+	for _, cookie := range cookies {
+		if cookie.Name == "jscookie" && cookie.Value == "fromjs" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatalf("expected jscookie in cookies got: %v\n", cookies)
+	}
+
+	if err := tab.SetCookieViaJS("httponly", "fromjs"); err == nil {
+		t.Fatalf("expected error overwriting an HttpOnly cookie")
+	}
+}
+
+func TestTabGetAllCookies(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "cookie1.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "index.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	cookies, err := tab.GetCookies()
+	if err != nil {
+		t.Fatalf("Error getting cookies: %s\n", err)
+	}
+
+	for _, cookie := range cookies {
+		if cookie.Name == "cookie1" {
+			t.Fatalf("cookie1 should not apply to index.html")
+		}
+	}
+
+	allCookies, err := tab.GetAllCookies()
+	if err != nil {
+		t.Fatalf("Error getting all cookies: %s\n", err)
+	}
+
+	for _, cookie := range allCookies {
+		if cookie.Name == "cookie1" {
+			return
+		}
+	}
+	t.Fatalf("expected cookie1 in all cookies")
 }
 
 func TestTabTwoTabCookies(t *testing.T) {
@@ -414,6 +3640,186 @@ func TestTabNetworkTraffic(t *testing.T) {
 
 }
 
+func TestTabCollectResponseBodies(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if err := tab.CollectResponseBodies(`debugger\.js$`); err != nil {
+		t.Fatalf("error collecting response bodies: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "debugger.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		return len(tab.GetCollectedBodies()) > 0
+	})
+	if err != nil {
+		t.Fatalf("error waiting for collected bodies: %s\n", err)
+	}
+	tab.StopCollectingResponseBodies()
+
+	bodies := tab.GetCollectedBodies()
+	if len(bodies) != 1 {
+		t.Fatalf("expected only debugger.js to be collected got %d bodies\n", len(bodies))
+	}
+
+	body, ok := bodies[testServerAddr+"debugger.js"]
+	if !ok || !strings.Contains(string(body), "function getValue()") {
+		t.Fatalf("unexpected collected body: %s\n", string(body))
+	}
+
+	tab.ClearCollectedBodies()
+	if bodies := tab.GetCollectedBodies(); len(bodies) != 0 {
+		t.Fatalf("expected no bodies after clearing got %d\n", len(bodies))
+	}
+
+	if err := tab.CollectResponseBodies("("); err == nil {
+		t.Fatalf("expected error for invalid pattern")
+	}
+}
+
+func TestTabNetworkRequestType(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	typesMutex := &sync.Mutex{}
+	types := make(map[string]string)
+	requestHandlerFn := func(callerTab *Tab, request *NetworkRequest) {
+		typesMutex.Lock()
+		types[request.Request.Url] = request.Type
+		typesMutex.Unlock()
+	}
+
+	if err := tab.GetNetworkTraffic(requestHandlerFn, nil, nil); err != nil {
+		t.Fatalf("Error listening to network traffic: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(testServerAddr + "debugger.html"); err != nil {
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+	tab.StopNetworkTraffic(true)
+
+	typesMutex.Lock()
+	defer typesMutex.Unlock()
+
+	if types[testServerAddr+"debugger.html"] != "Document" {
+		t.Fatalf("expected Document type got: %s\n", types[testServerAddr+"debugger.html"])
+	}
+
+	if types[testServerAddr+"debugger.js"] != "Script" {
+		t.Fatalf("expected Script type got: %s\n", types[testServerAddr+"debugger.js"])
+	}
+}
+
+func TestTabInFlightRequestCount(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body><script>fetch('/slow');</script></body></html>"))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("done"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if err := tab.GetNetworkTraffic(nil, nil, nil); err != nil {
+		t.Fatalf("Error listening to network traffic: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(server.URL); err != nil {
+		close(release)
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		return tab.InFlightRequestCount() == 1
+	})
+	close(release)
+	if err != nil {
+		t.Fatalf("expected the held request to be in flight got: %d\n", tab.InFlightRequestCount())
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		return tab.InFlightRequestCount() == 0
+	})
+	if err != nil {
+		t.Fatalf("requests still in flight after release: %d\n", tab.InFlightRequestCount())
+	}
+
+	if err := tab.StopNetworkTraffic(true); err != nil {
+		t.Fatalf("error stopping network traffic: %s\n", err)
+	}
+}
+
+func TestTabGetPendingRequests(t *testing.T) {
+	testAuto := testDefaultStartup(t)
+	defer testAuto.Shutdown()
+
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body><script>fetch('/slow');</script></body></html>"))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("done"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tab, err := testAuto.NewTab()
+	if err != nil {
+		t.Fatalf("error getting tab")
+	}
+
+	if err := tab.GetNetworkTraffic(nil, nil, nil); err != nil {
+		t.Fatalf("Error listening to network traffic: %s\n", err)
+	}
+
+	if _, errorText, err := tab.Navigate(server.URL); err != nil {
+		close(release)
+		t.Fatalf("Error navigating: %s %s\n", errorText, err)
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		pending := tab.GetPendingRequests()
+		return len(pending) == 1 && strings.HasSuffix(pending[0].Request.Url, "/slow")
+	})
+	close(release)
+	if err != nil {
+		t.Fatalf("expected only the slow request to be pending: %v\n", tab.GetPendingRequests())
+	}
+
+	err = tab.WaitFor(testWaitRate, testWaitTimeout, func(tab *Tab) bool {
+		return len(tab.GetPendingRequests()) == 0
+	})
+	if err != nil {
+		t.Fatalf("requests still pending after release: %d\n", len(tab.GetPendingRequests()))
+	}
+}
+
 func TestTabWindows(t *testing.T) {
 	testAuto := testDefaultStartup(t)
 	defer testAuto.Shutdown()
@@ -426,7 +3832,7 @@ func TestTabWindows(t *testing.T) {
 	}
 	tab.WaitStable()
 
-	t.Logf("# of elements: %d\n", len(tab.elements))
+	t.Logf("# of elements: %d\n", len(tab.GetAllElements()))
 	ele, _, err := tab.GetElementById("mainwindow")
 	if err != nil {
 		t.Fatalf("error getting mainwindow element")
@@ -0,0 +1,3 @@
+function getValue() {
+	return 1;
+}
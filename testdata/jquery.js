@@ -0,0 +1,5 @@
+// minimal stand in for jQuery so tests do not need internet access
+window.jQuery = window.$ = function(selector) {
+	return document.querySelectorAll(selector);
+};
+window.jQuery.fn = { jquery: 'autogcd-test' };
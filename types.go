@@ -25,6 +25,8 @@ THE SOFTWARE.
 package autogcd
 
 import (
+	"time"
+
 	"github.com/wirepair/gcd/gcdapi"
 )
 
@@ -101,6 +103,237 @@ type NodeChangeEvent struct {
 
 }
 
+// Options for Tab.ClickAt, the zero value is a single left click without modifiers.
+type ClickOptions struct {
+	Button     string // none, left, middle or right, defaults to left
+	ClickCount int    // number of clicks, defaults to 1
+	Alt        bool   // hold alt while clicking
+	Ctrl       bool   // hold ctrl while clicking
+	Meta       bool   // hold meta (command) while clicking
+	Shift      bool   // hold shift while clicking
+}
+
+// Returns the modifiers bit field used by Input.dispatchMouseEvent
+func (o ClickOptions) modifiers() int {
+	modifiers := 0
+	if o.Alt {
+		modifiers |= 1
+	}
+	if o.Ctrl {
+		modifiers |= 2
+	}
+	if o.Meta {
+		modifiers |= 4
+	}
+	if o.Shift {
+		modifiers |= 8
+	}
+	return modifiers
+}
+
+// Requests to intercept with Tab.SetRequestInterception, empty fields match all requests
+type InterceptPattern struct {
+	UrlPattern        string // wildcards ('*' zero or more, '?' exactly one) are allowed, defaults to *
+	ResourceType      string // Document, Stylesheet, Image, Media, Font, Script, XHR, Fetch etc.
+	InterceptionStage string // Request or HeadersReceived, chrome defaults to Request
+}
+
+// Returns the pattern as Network.setRequestInterception RequestPattern parameters
+func (p *InterceptPattern) params() map[string]interface{} {
+	params := make(map[string]interface{}, 3)
+	params["urlPattern"] = "*"
+	if p.UrlPattern != "" {
+		params["urlPattern"] = p.UrlPattern
+	}
+	if p.ResourceType != "" {
+		params["resourceType"] = p.ResourceType
+	}
+	if p.InterceptionStage != "" {
+		params["interceptionStage"] = p.InterceptionStage
+	}
+	return params
+}
+
+// A device for Tab.EmulateDevice
+type Device struct {
+	Width             int     // viewport width in css pixels
+	Height            int     // viewport height in css pixels
+	DeviceScaleFactor float64 // device pixels per css pixel
+	Mobile            bool    // emulate a mobile viewport (meta viewport, overlay scrollbars)
+	Touch             bool    // emulate a touch screen
+	UserAgent         string  // user agent to send
+}
+
+// Returns the parameters to override the viewport with the device's metrics
+func (d *Device) metricsParams() *gcdapi.EmulationSetDeviceMetricsOverrideParams {
+	return &gcdapi.EmulationSetDeviceMetricsOverrideParams{
+		Width:             d.Width,
+		Height:            d.Height,
+		DeviceScaleFactor: d.DeviceScaleFactor,
+		Mobile:            d.Mobile,
+		ScreenWidth:       d.Width,
+		ScreenHeight:      d.Height,
+	}
+}
+
+// Returns the number of touch points to emulate
+func (d *Device) maxTouchPoints() int {
+	if d.Touch {
+		return 5
+	}
+	return 1
+}
+
+// Common devices by name for Tab.EmulateDevice, add to it to emulate others.
+var DevicePresets = map[string]*Device{
+	"iPhone SE": {
+		Width: 375, Height: 667, DeviceScaleFactor: 2, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 13_2_3 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/13.0.3 Mobile/15E148 Safari/604.1",
+	},
+	"iPhone 12": {
+		Width: 390, Height: 844, DeviceScaleFactor: 3, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 14_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.0.3 Mobile/15E148 Safari/604.1",
+	},
+	"Pixel 5": {
+		Width: 393, Height: 851, DeviceScaleFactor: 2.75, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (Linux; Android 11; Pixel 5) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/90.0.4430.91 Mobile Safari/537.36",
+	},
+	"Galaxy S8": {
+		Width: 360, Height: 740, DeviceScaleFactor: 4, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (Linux; Android 7.0; SM-G950U Build/NRD90M) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/62.0.3202.84 Mobile Safari/537.36",
+	},
+	"iPad": {
+		Width: 768, Height: 1024, DeviceScaleFactor: 2, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (iPad; CPU OS 11_0 like Mac OS X) AppleWebKit/604.1.34 (KHTML, like Gecko) Version/11.0 Mobile/15A5341f Safari/604.1",
+	},
+	"iPad Pro": {
+		Width: 1024, Height: 1366, DeviceScaleFactor: 2, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (iPad; CPU OS 11_0 like Mac OS X) AppleWebKit/604.1.34 (KHTML, like Gecko) Version/11.0 Mobile/15A5341f Safari/604.1",
+	},
+	"Laptop": {
+		Width: 1366, Height: 768, DeviceScaleFactor: 1,
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/64.0.3282.140 Safari/537.36",
+	},
+}
+
+// Size of the text of an element as laid out, returned by Element.GetTextMetrics
+type TextMetrics struct {
+	Width  float64 `json:"width"`  // width of the box bounding all of the text
+	Height float64 `json:"height"` // height of the box bounding all of the text
+	Lines  int     `json:"lines"`  // number of lines the text is wrapped on to
+}
+
+// A rectangle in css pixels relative to the viewport, returned by Element.GetBoundingBox
+type Rect struct {
+	X      float64 // left edge
+	Y      float64 // top edge
+	Width  float64 // width of the rectangle
+	Height float64 // height of the rectangle
+}
+
+// A form field filled by Tab.FillForm
+type FormField struct {
+	Selector string // selector of the field in the top document, the first match is filled
+	Value    string // value typed into the field
+}
+
+// An image in the page returned by Tab.GetImages
+type ImageInfo struct {
+	Src           string `json:"src"`           // absolute url of the image
+	Alt           string `json:"alt"`           // alternative text, empty if missing
+	HasAlt        bool   `json:"hasAlt"`        // true if the alt attribute is present, even if empty
+	NaturalWidth  int    `json:"naturalWidth"`  // intrinsic width, 0 if not loaded
+	NaturalHeight int    `json:"naturalHeight"` // intrinsic height, 0 if not loaded
+}
+
+// The response of a fetch made from the page by Tab.FetchInPage
+type FetchResult struct {
+	Status     int               `json:"status"`     // http status code
+	StatusText string            `json:"statusText"` // http status text
+	Url        string            `json:"url"`        // final url after any redirects
+	Headers    map[string]string `json:"headers"`    // response headers, names are lower case
+	Body       string            `json:"body"`       // response body as text
+}
+
+// Thresholds for Tab.WaitForPageReadyWithOptions, zero values use the defaults.
+type PageReadyOptions struct {
+	NetworkIdleTime     time.Duration // how long the network must be idle, defaults to 500ms
+	MaxInflightRequests int           // requests which may still be pending while idle, defaults to 0
+	DOMQuietPeriod      time.Duration // how long without DOM mutations, defaults to 500ms
+	CheckRate           time.Duration // how often the conditions are checked, defaults to 100ms
+}
+
+// Returns a copy of the options with defaults filled in for unset thresholds
+func (o *PageReadyOptions) withDefaults() *PageReadyOptions {
+	opts := &PageReadyOptions{}
+	if o != nil {
+		*opts = *o
+	}
+	if opts.NetworkIdleTime <= 0 {
+		opts.NetworkIdleTime = 500 * time.Millisecond
+	}
+	if opts.MaxInflightRequests < 0 {
+		opts.MaxInflightRequests = 0
+	}
+	if opts.DOMQuietPeriod <= 0 {
+		opts.DOMQuietPeriod = 500 * time.Millisecond
+	}
+	if opts.CheckRate <= 0 {
+		opts.CheckRate = 100 * time.Millisecond
+	}
+	return opts
+}
+
+// Options for Tab.PrintToPDF, sizes are in inches. The zero value prints a letter sized portrait page
+// without margins or backgrounds.
+type PDFOptions struct {
+	Landscape       bool    // print in landscape orientation
+	PrintBackground bool    // print background graphics
+	PaperWidth      float64 // defaults to 8.5
+	PaperHeight     float64 // defaults to 11
+	MarginTop       float64 // defaults to 0
+	MarginBottom    float64 // defaults to 0
+	MarginLeft      float64 // defaults to 0
+	MarginRight     float64 // defaults to 0
+	Scale           float64 // scale of the page rendering, defaults to 1
+}
+
+// Returns the options as Page.printToPDF parameters
+func (o *PDFOptions) params() map[string]interface{} {
+	params := make(map[string]interface{}, 9)
+	params["landscape"] = o.Landscape
+	params["printBackground"] = o.PrintBackground
+	params["marginTop"] = o.MarginTop
+	params["marginBottom"] = o.MarginBottom
+	params["marginLeft"] = o.MarginLeft
+	params["marginRight"] = o.MarginRight
+	if o.PaperWidth > 0 {
+		params["paperWidth"] = o.PaperWidth
+	}
+	if o.PaperHeight > 0 {
+		params["paperHeight"] = o.PaperHeight
+	}
+	if o.Scale > 0 {
+		params["scale"] = o.Scale
+	}
+	return params
+}
+
+// A frame of the page returned by Tab.GetAccessibleFrames
+type FrameInfo struct {
+	FrameId    string // id of the frame
+	Url        string // url of the frame's document
+	Accessible bool   // true if the frame's document can be queried from this tab
+}
+
+// Details of the certificate a page was served with
+type CertInfo struct {
+	Subject   string    // distinguished name of the certificate subject
+	Issuer    string    // distinguished name of the certificate issuer
+	ValidFrom time.Time // certificate is not valid before this time
+	ValidTo   time.Time // certificate is not valid after this time
+}
+
 // Outbound network requests
 type NetworkRequest struct {
 	RequestId        string                   // Internal chrome request id
@@ -124,6 +357,41 @@ type NetworkResponse struct {
 	Type      string                  // Document, Stylesheet, Image, Media, Font, Script, TextTrack, XHR, Fetch, EventSource, WebSocket, Other
 }
 
+// A performance timeline event reported to Tab.CollectPerformanceTimeline
+type TimelineEvent struct {
+	FrameId            string                  `json:"frameId"`            // frame the event occurred in
+	Type               string                  `json:"type"`               // largest-contentful-paint, layout-shift etc
+	Name               string                  `json:"name"`               // name of the event, may be empty
+	Time               float64                 `json:"time"`               // time in seconds since epoch
+	Duration           float64                 `json:"duration"`           // event duration in seconds, if applicable
+	LcpDetails         *LargestContentfulPaint `json:"lcpDetails"`         // set for largest-contentful-paint events
+	LayoutShiftDetails *LayoutShift            `json:"layoutShiftDetails"` // set for layout-shift events
+}
+
+// Details of a largest-contentful-paint timeline event
+type LargestContentfulPaint struct {
+	RenderTime float64 `json:"renderTime"` // time the element was rendered
+	LoadTime   float64 `json:"loadTime"`   // time the element's resource loaded, 0 for text
+	Size       float64 `json:"size"`       // area of the element in pixels
+	ElementId  string  `json:"elementId"`  // id attribute of the element, may be empty
+	Url        string  `json:"url"`        // url of the image, empty for text
+	NodeId     int     `json:"nodeId"`     // backend node id of the element
+}
+
+// Details of a layout-shift timeline event
+type LayoutShift struct {
+	Value          float64 `json:"value"`          // layout shift score
+	HadRecentInput bool    `json:"hadRecentInput"` // true if the shift followed user input
+	LastInputTime  float64 `json:"lastInputTime"`  // time of the most recent input
+}
+
+type timelineEventAddedEvent struct {
+	Method string `json:"method"`
+	Params struct {
+		Event *TimelineEvent `json:"event"`
+	} `json:"Params,omitempty"`
+}
+
 // For storage related events.
 type StorageEventType uint16
 